@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"epic-gateway.org/epicctl/internal/htpasswd"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+// apiUsersRotatedAnnotation is the api-users secret annotation that
+// records when each user's password was last set. Its value is a
+// JSON object that maps user names to RFC3339 timestamps. User names
+// can contain characters that aren't allowed in annotation keys so
+// we can't use one annotation per user.
+const apiUsersRotatedAnnotation = "epic-gateway.org/api-users-rotated"
+
+// getAPIUsersSecret reads the api-users secret in the user namespace
+// accountName.
+func getAPIUsersSecret(ctx context.Context, cl client.Client, accountName string) (*v1.Secret, error) {
+	secret := v1.Secret{}
+	err := cl.Get(ctx, client.ObjectKey{Namespace: epicv1.AccountNamespace(accountName), Name: contourSecretName}, &secret)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("user namespace %s not found", accountName)
+		}
+		return nil, err
+	}
+
+	return &secret, nil
+}
+
+// modifyAPIUsers changes the api-users secret in the user namespace
+// accountName. It reads the secret, calls modify to change the
+// secret's users (and, if need be, the secret itself), and updates
+// the secret. If someone else changes the secret in the meantime
+// then the update fails with a conflict so we start over with a
+// fresh copy. That way concurrent changes are never lost. modify can
+// be called more than once so it shouldn't have side effects.
+func modifyAPIUsers(ctx context.Context, cl client.Client, accountName string, modify func(secret *v1.Secret, users *htpasswd.File) error) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := getAPIUsersSecret(ctx, cl, accountName)
+		if err != nil {
+			return err
+		}
+		users := parseAPIUsers(secret)
+
+		if err := modify(secret, users); err != nil {
+			return err
+		}
+		saveAPIUsers(secret, users)
+
+		return cl.Update(ctx, secret)
+	})
+	if apierrors.IsConflict(err) {
+		return fmt.Errorf("the %s secret in user-namespace %s is changing too often to update, try again: %w", contourSecretName, accountName, err)
+	}
+
+	return err
+}
+
+// parseAPIUsers parses the htpasswd data in the api-users secret.
+// Lines that can't be parsed are kept as-is.
+func parseAPIUsers(secret *v1.Secret) *htpasswd.File {
+	users := htpasswd.Parse(secret.Data["auth"])
+	for _, problem := range users.Problems() {
+		Debug("the %s secret in %s is malformed: %s\n", secret.Name, secret.Namespace, problem)
+	}
+	return users
+}
+
+// saveAPIUsers stores users in the api-users secret. The caller
+// still needs to update the secret in the cluster, which it should
+// do with modifyAPIUsers.
+func saveAPIUsers(secret *v1.Secret, users *htpasswd.File) {
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data["auth"] = users.Bytes()
+}
+
+// rotationTimes returns the times at which the passwords of the
+// users in secret were last set. Users whose passwords were set
+// before we started keeping track aren't in the map. If the
+// annotation is corrupt then we ignore it rather than failing.
+func rotationTimes(secret *v1.Secret) map[string]string {
+	times := map[string]string{}
+	if value, ok := secret.Annotations[apiUsersRotatedAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &times); err != nil {
+			Debug("ignoring corrupt %s annotation: %s\n", apiUsersRotatedAnnotation, err)
+			return map[string]string{}
+		}
+	}
+	return times
+}
+
+// setRotated records in secret that user's password was set at t. If
+// t is zero then user's record is removed.
+func setRotated(secret *v1.Secret, user string, t time.Time) {
+	times := rotationTimes(secret)
+	if t.IsZero() {
+		delete(times, user)
+	} else {
+		times[user] = t.UTC().Format(time.RFC3339)
+	}
+	setRotationTimes(secret, times)
+}
+
+// setRotationTimes replaces the rotation times recorded in secret.
+func setRotationTimes(secret *v1.Secret, times map[string]string) {
+	value, _ := json.Marshal(times)
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[apiUsersRotatedAnnotation] = string(value)
+}
+
+// keepRotationTimes records in secret, which is about to replace the
+// live api-users secret, when the password of each of users was last
+// set. Users whose hashes are the same as in the live secret keep
+// their live times (if they have one), and the others, i.e., new users
+// and users whose passwords are changing, get the current time. The
+// times of users who are being removed are dropped.
+func keepRotationTimes(ctx context.Context, cl client.Client, secret *v1.Secret, users *htpasswd.File) error {
+	live := v1.Secret{}
+	liveUsers := htpasswd.New()
+	liveTimes := map[string]string{}
+	tracked := false
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(secret), &live); err == nil {
+		liveUsers = parseAPIUsers(&live)
+		liveTimes = rotationTimes(&live)
+		_, tracked = live.Annotations[apiUsersRotatedAnnotation]
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	times := map[string]string{}
+	for _, entry := range users.Entries() {
+		liveEntry, exists := liveUsers.Get(entry.User)
+		switch {
+		case !exists || liveEntry.Hash != entry.Hash:
+			times[entry.User] = now
+		case liveTimes[entry.User] != "":
+			times[entry.User] = liveTimes[entry.User]
+		}
+	}
+
+	// Don't add an empty annotation to a secret that's never had one.
+	if len(times) > 0 || tracked {
+		setRotationTimes(secret, times)
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"epic-gateway.org/epicctl/internal/htpasswd"
+	"epic-gateway.org/epicctl/internal/printer"
+	"epic-gateway.org/epicctl/internal/tenant"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+// applyFieldOwner is the field manager that epicctl uses for
+// server-side apply.
+const applyFieldOwner = "epicctl"
+
+func init() {
+	var specFile string
+
+	applyCmd := &cobra.Command{
+		Use:   "apply -f tenant.yaml",
+		Short: "Applies a tenant spec",
+		Long: `Applies a declarative tenant spec to EPIC.
+
+The spec describes a user namespace and everything in it: the
+registry credentials, the API users (with bcrypt password hashes,
+never plaintext passwords), the ad-hoc Gateways and the ad-hoc
+endpoints. For example:
+
+  name: acme
+  registry:
+    username: acme-deploy
+    password: xxxxxxxx
+  apiUsers:
+  - name: alice
+    passwordHash: $2y$10$...
+  gateways:
+  - name: web
+    port: 80
+    serviceGroup: gatewayhttps
+    clusterName: linux-nodes
+  endpoints:
+  - name: node1
+    address: 192.168.1.10
+    port: 8080
+    clusterName: linux-nodes
+
+The objects are applied using server-side apply so they can be
+re-applied safely as the spec changes. Only the fields that the spec
+describes are applied so the fields that EPIC's controllers maintain
+are left alone. The API users in the spec replace the ones in the
+cluster. Users that are new, or whose password hashes have changed,
+are recorded as having their passwords rotated now. Objects that have
+been removed from the spec are not deleted.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spec, err := tenant.Load(specFile)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", specFile, err)
+			}
+
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			objs, err := tenantObjects(rootCmd.Context(), cl, spec)
+			if err != nil {
+				return err
+			}
+
+			return applyObjects(rootCmd.Context(), cl, objs)
+		},
+	}
+	applyCmd.Flags().StringVarP(&specFile, "filename", "f", "", "the tenant spec file")
+	applyCmd.MarkFlagRequired("filename")
+	rootCmd.AddCommand(applyCmd)
+}
+
+// tenantObjects builds the objects described by spec, in the order
+// in which they need to be applied. It reads the live api-users
+// secret to work out the users' password rotation times.
+func tenantObjects(ctx context.Context, cl client.Client, spec *tenant.Spec) ([]client.Object, error) {
+	nsName := epicv1.AccountNamespace(spec.Name)
+	ns := userNamespace(spec.Name)
+	acct := userAccount(spec.Name)
+	objs := []client.Object{&ns, &acct}
+
+	if spec.Registry != nil {
+		secret, err := dockerSecret(gitlabSecretName, nsName, gitlabRegistryHostname, spec.Registry.Username, spec.Registry.Password)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, &secret)
+	}
+
+	pwObj, err := contourSecret(contourSecretName, nsName, contourRealmName)
+	if err != nil {
+		return nil, err
+	}
+	users := htpasswd.New()
+	for _, user := range spec.APIUsers {
+		if _, err := users.Set(user.Name, user.PasswordHash); err != nil {
+			return nil, fmt.Errorf("api-user %s: %w", user.Name, err)
+		}
+	}
+	saveAPIUsers(&pwObj, users)
+	if err := keepRotationTimes(ctx, cl, &pwObj, users); err != nil {
+		return nil, err
+	}
+	objs = append(objs, &pwObj)
+
+	for _, gw := range spec.Gateways {
+		proxy := adHocProxy(spec.Name, gw.Name, gw.ServiceGroup, []v1alpha2.Listener{httpListener(gw.Port)})
+		route := adHocRoute(spec.Name, gw.Name, gw.Port, gw.ClusterName)
+		objs = append(objs, &proxy, &route)
+	}
+
+	for _, ep := range spec.Endpoints {
+		slice := adHocEndpointSlice(spec.Name, ep.ClusterName, ep.Name, net.ParseIP(ep.Address), ep.Port)
+		objs = append(objs, &slice)
+	}
+
+	return objs, nil
+}
+
+// applyObjects server-side applies objs, in order, and prints
+// whether each one was created, updated or unchanged. It stops at
+// the first failure.
+func applyObjects(ctx context.Context, cl client.Client, objs []client.Object) error {
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "Object"},
+			{Header: "Result"},
+		},
+	}
+	defer table.Print(os.Stdout, printer.Default)
+
+	for _, obj := range objs {
+		name := describeObject(obj)
+		result, err := applyObject(ctx, cl, obj)
+		if err != nil {
+			table.Append(name, obj, name, "failed")
+			return fmt.Errorf("applying %s: %w", name, err)
+		}
+		table.Append(name, obj, name, result)
+	}
+
+	return nil
+}
+
+// applyObject server-side applies obj and returns "created",
+// "updated" or "unchanged". The API server doesn't change an object's
+// resourceVersion if an apply doesn't change anything so we compare
+// the before and after versions.
+func applyObject(ctx context.Context, cl client.Client, obj client.Object) (string, error) {
+	patch, err := applyConfiguration(obj)
+	if err != nil {
+		return "", err
+	}
+
+	existing := obj.DeepCopyObject().(client.Object)
+	before := ""
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(obj), existing); err == nil {
+		before = existing.GetResourceVersion()
+	} else if !apierrors.IsNotFound(err) {
+		return "", err
+	}
+
+	if err := cl.Patch(ctx, patch, client.Apply, client.ForceOwnership, client.FieldOwner(applyFieldOwner)); err != nil {
+		return "", err
+	}
+
+	switch {
+	case before == "":
+		return "created", nil
+	case before != patch.GetResourceVersion():
+		return "updated", nil
+	default:
+		return "unchanged", nil
+	}
+}
+
+// applyFields are, for each kind of object that a tenant spec
+// describes, the fields that the spec declares. Server-side apply
+// takes ownership of every field in a patch, and a typed object has a
+// value for every field that isn't omitempty, so applying one as-is
+// would claim (and, with ForceOwnership, reset) fields that the
+// controllers own, e.g., a GWProxy's endpoints. The declared fields
+// are applied as they are, even if their values are empty, e.g., the
+// api-users secret's data when the spec has no API users.
+var applyFields = map[string][][]string{
+	"Namespace": {{"metadata", "labels"}},
+	"Account":   {{"metadata", "labels"}},
+	"Secret": {
+		{"metadata", "labels"},
+		{"metadata", "annotations"},
+		{"type"},
+		{"data"},
+	},
+	"GWProxy": {
+		{"metadata", "labels"},
+		{"spec", "clientRef", "namespace"},
+		{"spec", "display-name"},
+		{"spec", "gateway", "listeners"},
+	},
+	"GWRoute": {
+		{"metadata", "labels"},
+		{"spec", "http"},
+		{"spec", "tls"},
+	},
+	"GWEndpointSlice": {
+		{"metadata", "labels"},
+		{"spec", "parentRef", "uid"},
+		{"spec", "addressType"},
+		{"spec", "endpoints"},
+		{"spec", "ports"},
+		{"spec", "nodeAddresses"},
+	},
+}
+
+// applyConfiguration converts obj into the patch that applies it,
+// which has obj's identity and its applyFields. An unstructured obj,
+// e.g., from an archive made by "epicctl export", only has the fields
+// that it declares so it's applied as-is.
+func applyConfiguration(obj client.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.DeepCopy(), nil
+	}
+
+	// Apply patches need the object's TypeMeta.
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := applyFields[gvk.Kind]
+	if !ok {
+		return nil, fmt.Errorf("%s objects can't be applied", gvk.Kind)
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	patch.SetGroupVersionKind(gvk)
+	patch.SetName(obj.GetName())
+	patch.SetNamespace(obj.GetNamespace())
+	for _, field := range fields {
+		value, found, err := unstructured.NestedFieldCopy(content, field...)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		if err := unstructured.SetNestedField(patch.Object, value, field...); err != nil {
+			return nil, err
+		}
+	}
+
+	return patch, nil
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// apiUserCheckOptions configure an API user check.
+type apiUserCheckOptions struct {
+	PasswordStdin bool
+	PasswordFile  string
+
+	// URL is a web service URL to request with the user's
+	// credentials. If it's "" then we only check offline.
+	URL      string
+	Insecure bool
+	Timeout  time.Duration
+}
+
+func init() {
+	opts := apiUserCheckOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "api-user username user-namespace",
+		Aliases: []string{"api-users"},
+		Short:   "Check an api-user's password",
+		Long: `Check that a password is correct for api-user username.
+
+The password is read from the terminal (or --password-stdin or
+--password-file) and compared with the hash in the user namespace's
+api-users secret.
+
+If --url is given then epicctl also requests that EPIC web service
+URL with the user's credentials to check that authentication works
+end-to-end, e.g.:
+
+  epicctl check api-user alice acme --url https://epic.example.com/api/epic/accounts/acme`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.PasswordStdin && opts.PasswordFile != "" {
+				return fmt.Errorf("only one of --password-stdin and --password-file can be used")
+			}
+
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			return checkAPIUser(rootCmd.Context(), cl, args[0], args[1], opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.PasswordStdin, "password-stdin", false, "read the password from the first line of stdin")
+	cmd.Flags().StringVar(&opts.PasswordFile, "password-file", "", "read the password from the first line of this file")
+	cmd.Flags().StringVar(&opts.URL, "url", "", "EPIC web service URL to request with the user's credentials")
+	cmd.Flags().BoolVar(&opts.Insecure, "insecure-skip-tls-verify", false, "don't verify the web service's TLS certificate")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 10*time.Second, "how long to wait for the web service")
+	checkCmd.AddCommand(cmd)
+}
+
+// checkAPIUser checks a password for apiUser in the user namespace
+// accountName, first against the stored hash and then, optionally,
+// against the web service.
+func checkAPIUser(ctx context.Context, cl client.Client, apiUser string, accountName string, opts apiUserCheckOptions) error {
+	secret, err := getAPIUsersSecret(ctx, cl, accountName)
+	if err != nil {
+		return err
+	}
+	users := parseAPIUsers(secret)
+	entry, found := users.Get(apiUser)
+	if !found {
+		return fmt.Errorf("api-user %s not found in user-namespace %s", apiUser, accountName)
+	}
+
+	password, err := readCheckPassword(opts)
+	if err != nil {
+		return err
+	}
+
+	match, err := entry.Verify(password)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return fmt.Errorf("password for api-user %s is wrong", apiUser)
+	}
+	fmt.Printf("password for api-user %s matches the stored %s hash\n", apiUser, entry.Algorithm())
+
+	if opts.URL == "" {
+		return nil
+	}
+
+	realm := secret.Annotations[contourRealmAnnotation]
+	return checkWebServiceAuth(ctx, apiUser, password, realm, opts)
+}
+
+// readCheckPassword reads the password to check from the source in
+// opts.
+func readCheckPassword(opts apiUserCheckOptions) (string, error) {
+	switch {
+	case opts.PasswordStdin:
+		return readPasswordLine(os.Stdin)
+	case opts.PasswordFile != "":
+		f, err := os.Open(opts.PasswordFile)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		return readPasswordLine(f)
+	}
+
+	fmt.Print("Password:  ")
+	password, err := readPassword()
+	fmt.Println()
+	return password, err
+}
+
+// checkWebServiceAuth requests opts.URL with apiUser's credentials.
+// If the web service rejects them then it explains why, using realm
+// to tell whether the rejection came from the user namespace's
+// authentication or something else.
+func checkWebServiceAuth(ctx context.Context, apiUser string, password string, realm string, opts apiUserCheckOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(apiUser, password)
+
+	httpClient := http.Client{}
+	if opts.Insecure {
+		httpClient.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting %s: %w", opts.URL, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		challenged := challengeRealm(resp.Header.Get("WWW-Authenticate"))
+		if realm != "" && challenged != realm {
+			return fmt.Errorf("web service rejected the credentials but asked for realm %q instead of %q, is %s the right URL?", challenged, realm, opts.URL)
+		}
+		return fmt.Errorf("web service rejected the credentials (%s), the secret might not have been loaded yet", resp.Status)
+	case resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("web service accepted the credentials but api-user %s isn't allowed to access %s (%s)", apiUser, opts.URL, resp.Status)
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("web service failed (%s)", resp.Status)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("web service returned %s, is %s the right URL?", resp.Status, opts.URL)
+	}
+
+	fmt.Printf("web service accepted the credentials for api-user %s (%s)\n", apiUser, resp.Status)
+	return nil
+}
+
+// challengeRealm returns the realm parameter of a WWW-Authenticate
+// challenge, e.g., "epic" from `Basic realm="epic"`, or "" if it
+// doesn't have one.
+func challengeRealm(challenge string) string {
+	i := strings.Index(strings.ToLower(challenge), "realm=")
+	if i < 0 {
+		return ""
+	}
+	value := challenge[i+len("realm="):]
+
+	if !strings.HasPrefix(value, `"`) {
+		// A token, which ends at the next parameter or challenge.
+		if end := strings.IndexAny(value, ", "); end >= 0 {
+			value = value[:end]
+		}
+		return value
+	}
+
+	// A quoted string, in which a backslash escapes the next
+	// character.
+	realm := strings.Builder{}
+	for j := 1; j < len(value); j++ {
+		switch value[j] {
+		case '\\':
+			if j+1 < len(value) {
+				j++
+				realm.WriteByte(value[j])
+			}
+		case '"':
+			return realm.String()
+		default:
+			realm.WriteByte(value[j])
+		}
+	}
+	return realm.String()
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// checkCmd is a container command for the subcommands that verify
+// that resources work.
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Checks resources",
+	Long:  `Checks that resources in EPIC work as expected.`,
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
@@ -40,7 +40,7 @@ Arguments:
 		PreRunE: parseInput,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// We'll need a Client to interact with the Epic cluster.
-			cl, err := getCRClient()
+			cl, err := getCreateClient()
 			if err != nil {
 				panic(err.Error())
 			}
@@ -88,8 +88,26 @@ func parseInput(cmd *cobra.Command, args []string) error {
 // need from the node on which we're running, and then creating a
 // GWEndpointSlice on Epic.
 func createAdHocEndpoint(ctx context.Context, cl crclient.Client, groupName string, clusterName string, hostName string, ipAddress net.IP, port int32) error {
+	slice := adHocEndpointSlice(groupName, clusterName, hostName, ipAddress, port)
+
+	err := cl.Create(ctx, &slice)
+	if err != nil {
+		return err
+	}
+	createLog("endpoint %s in cluster %s created%s\n", hostName, clusterName, dryRunSuffix())
+
+	return nil
+}
+
+// adHocEndpointSlice builds the GWEndpointSlice that adds the host
+// hostName at ipAddress:port to the endpoint cluster clusterName.
+func adHocEndpointSlice(groupName string, clusterName string, hostName string, ipAddress net.IP, port int32) epicv1.GWEndpointSlice {
 	proto := v1.ProtocolTCP
-	slice := epicv1.GWEndpointSlice{
+	addressType := discoveryv1.AddressTypeIPv4
+	if ipAddress.To4() == nil {
+		addressType = discoveryv1.AddressTypeIPv6
+	}
+	return epicv1.GWEndpointSlice{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      hostName,
 			Namespace: epicv1.AccountNamespace(groupName),
@@ -102,11 +120,11 @@ func createAdHocEndpoint(ctx context.Context, cl crclient.Client, groupName stri
 				UID: clusterName,
 			},
 			EndpointSlice: discoveryv1.EndpointSlice{
-				AddressType: discoveryv1.AddressTypeIPv4,
+				AddressType: addressType,
 				Endpoints: []discoveryv1.Endpoint{
 					{
 						NodeName:  pointer.StringPtr(hostName),
-						Addresses: []string{hostAddress.String()},
+						Addresses: []string{ipAddress.String()},
 					},
 				},
 				Ports: []discoveryv1.EndpointPort{{
@@ -115,15 +133,8 @@ func createAdHocEndpoint(ctx context.Context, cl crclient.Client, groupName stri
 				}},
 			},
 			NodeAddresses: map[string]string{
-				hostName: hostAddress.String(),
+				hostName: ipAddress.String(),
 			},
 		},
 	}
-
-	err := cl.Create(ctx, &slice)
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
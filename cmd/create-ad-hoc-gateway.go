@@ -3,24 +3,34 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/gateway-api/apis/v1alpha2"
 
+	"epic-gateway.org/epicctl/internal/routerule"
+	"epic-gateway.org/epicctl/internal/versioned"
+
 	epicv1 "epic-gateway.org/resource-model/api/v1"
 )
 
 func init() {
-	var serviceGroup string
+	var (
+		serviceGroup string
+		timeout      time.Duration
+		listeners    []string
+		tlsCert      string
+		tlsKey       string
+		clusterNames []string
+	)
 
 	// Set up this command and hook it into its parent, the create
 	// command.
 	cmd := cobra.Command{
-		Use:     "ad-hoc-gateway name port",
+		Use:     "ad-hoc-gateway name [port]",
 		Short:   "Create ad-hoc Gateway",
 		Aliases: []string{"ad-hoc-gw"},
 		Long: `Create an ad-hoc EPIC Gateway.
@@ -29,41 +39,229 @@ EPIC can route traffic to ad-hoc Linux endpoints (i.e., endpoints that aren't ma
 
 This command creates an ad-hoc Gateway, which is the first step towards building a cluster of ad-hoc endpoints.
 
+A Gateway with a port argument has one HTTP listener on that port.
+For other protocols, or more than one listener, use --listener
+proto:port[:name] instead (it can be repeated). The protocols are:
+
+  http  - plain HTTP
+  https - HTTPS, terminated by the Gateway using the certificate and
+          key in --tls-cert and --tls-key (they're stored in a
+          Secret called <name>-tls)
+  tls   - TLS passthrough, the encrypted traffic is passed on as-is
+
+The Gateway sends its traffic to the ad-hoc endpoint cluster in
+--cluster-name (see "create ad-hoc-endpoint"). HTTP and HTTPS traffic
+goes through a route called <name>, and TLS passthrough traffic goes
+through a route called <name>-tls. If --cluster-name is repeated then
+the traffic is split between the clusters, in proportion to their
+weights if they're given as cluster:weight. To route different HTTP
+requests to different clusters, use "create ad-hoc-route".
+
+For example, to serve both HTTP and HTTPS:
+
+  epicctl create ad-hoc-gateway web --listener http:80 \
+    --listener https:443 --tls-cert web.crt --tls-key web.key
+
+If any of the Gateway's objects can't be created then the ones that
+were created are deleted, so the command can simply be run again.
+Once the Gateway is created, this command waits for EPIC to assign
+its external address, for up to --timeout (0 means don't wait). If the
+Gateway doesn't get an address in time then the command explains
+what might be holding it up.
+
 Arguments:
  name - the Gateway's name (must be unique within your account)
- port - the port on which the Gateway will receive traffic (32-bit int)
+ port - the port on which the Gateway will receive HTTP traffic (32-bit int)
 `,
-		Args: cobra.ExactArgs(2),
+		Args:         cobra.RangeArgs(1, 2),
+		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// We'll need a Client to interact with the Epic cluster.
-			cl, err := getCRClient()
+			name := args[0]
+
+			gwListeners, err := gatewayListeners(args[1:], listeners, name+"-tls")
 			if err != nil {
-				panic(err.Error())
+				return err
 			}
 
-			// Parse the port argument.
-			if port, err := strconv.ParseInt(args[1], 10, 32); err != nil {
-				return fmt.Errorf("can't parse %s as a port value: %w", args[1], err)
-			} else {
-				// Create the GWEndpointSlice.
-				if err := createAdHocGateway(rootCmd.Context(), cl, accountName, args[0], int32(port), serviceGroup); err != nil {
+			// HTTPS listeners need a certificate, and nothing else does.
+			var certSecret *v1.Secret
+			if hasProtocol(gwListeners, v1alpha2.HTTPSProtocolType) {
+				if tlsCert == "" || tlsKey == "" {
+					return fmt.Errorf("https listeners need --tls-cert and --tls-key")
+				}
+				secret, err := tlsSecret(name+"-tls", epicv1.AccountNamespace(accountName), accountName, tlsCert, tlsKey)
+				if err != nil {
 					return err
 				}
+				certSecret = &secret
+			} else if tlsCert != "" || tlsKey != "" {
+				return fmt.Errorf("--tls-cert and --tls-key can only be used with https listeners")
+			}
+
+			// We'll need a Client to interact with the Epic cluster.
+			cl, err := getCreateClient()
+			if err != nil {
+				return err
 			}
 
-			return nil
+			return createAdHocGateway(rootCmd.Context(), cl, accountName, name, gwListeners, certSecret, clusterNames, serviceGroup, timeout)
 		},
 	}
 	cmd.Flags().StringVar(&serviceGroup, "service-group", "gatewayhttps", "the service group to which the Gateway will belong")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "how long to wait for the Gateway's address, 0 to not wait")
+	cmd.Flags().StringArrayVar(&listeners, "listener", []string{}, "a listener, proto:port[:name], where proto is http, https or tls (can be repeated)")
+	cmd.Flags().StringVar(&tlsCert, "tls-cert", "", "the PEM-encoded certificate file for https listeners")
+	cmd.Flags().StringVar(&tlsKey, "tls-key", "", "the PEM-encoded private key file for https listeners")
+	cmd.Flags().StringArrayVar(&clusterNames, "cluster-name", []string{"linux-nodes"}, "the endpoint cluster, cluster[:weight], to which the Gateway sends traffic (can be repeated)")
 	createCmd.AddCommand(&cmd)
 }
 
 // createAdHocGateway implements the behind-the-scenes work for the
 // "ad-hoc-gateway" command. It's mostly just figuring out what we
-// need, and then creating a GWProxy on EPIC.
-func createAdHocGateway(ctx context.Context, cl crclient.Client, account string, name string, port int32, serviceGroup string) error {
-	portNum := v1alpha2.PortNumber(port)
-	proxy := epicv1.GWProxy{
+// need, and then creating a GWProxy and its GWRoutes (and the
+// certificate Secret, if there is one) on EPIC, or none of them if
+// something goes wrong. Then it waits up to timeout for
+// the GWProxy to get its external address.
+func createAdHocGateway(ctx context.Context, cl crclient.Client, account string, name string, listeners []v1alpha2.Listener, certSecret *v1.Secret, clusterNames []string, serviceGroup string, timeout time.Duration) error {
+	// Each route attaches to the listeners that carry its protocol, and
+	// its backends receive traffic on the first of those listeners'
+	// ports. HTTP routes attach to HTTP and HTTPS listeners, and TLS
+	// routes to TLS passthrough listeners.
+	clusterRule := func(port int32) (v1alpha2.HTTPRouteRule, error) {
+		rule := v1alpha2.HTTPRouteRule{}
+		for _, clusterName := range clusterNames {
+			backend, err := routerule.ParseBackend(clusterName, port)
+			if err != nil {
+				return rule, fmt.Errorf("--cluster-name %s: %w", clusterName, err)
+			}
+			rule.BackendRefs = append(rule.BackendRefs, backend)
+		}
+		return rule, nil
+	}
+
+	routes := []epicv1.GWRoute{}
+	if sections, port := routeListeners(listeners, v1alpha2.HTTPProtocolType, v1alpha2.HTTPSProtocolType); len(sections) > 0 {
+		rule, err := clusterRule(port)
+		if err != nil {
+			return err
+		}
+		routes = append(routes, adHocHTTPRoute(account, name, name, sections, nil, []v1alpha2.HTTPRouteRule{rule}))
+	}
+	if sections, port := routeListeners(listeners, v1alpha2.TLSProtocolType); len(sections) > 0 {
+		rule, err := clusterRule(port)
+		if err != nil {
+			return err
+		}
+		routes = append(routes, adHocTLSRoute(account, name+"-tls", name, sections, rule.BackendRefs))
+	}
+
+	// Every Gateway has at least one listener so it has at least one
+	// route, and they all send traffic to the same clusters, so the
+	// port doesn't matter here.
+	rule, err := clusterRule(0)
+	if err != nil {
+		return err
+	}
+	if err := warnEmptyClusters(ctx, cl, account, []v1alpha2.HTTPRouteRule{rule}); err != nil {
+		return err
+	}
+
+	// Create the certificate Secret first so it's there when the
+	// GWProxy needs it, and the GWProxy before the GWRoutes that
+	// attach to it. If something fails then we remove what we created
+	// so the command can be run again. Objects that already exist, e.g.,
+	// from an earlier run whose rollback failed, are adopted as-is.
+	proxy := adHocProxy(account, name, serviceGroup, listeners)
+	objs := []crclient.Object{}
+	if certSecret != nil {
+		objs = append(objs, certSecret)
+	}
+	objs = append(objs, &proxy)
+	for i := range routes {
+		objs = append(objs, &routes[i])
+	}
+
+	p := newProvisioner(cl)
+	for _, obj := range objs {
+		if err := p.ensure(ctx, obj, nil); err != nil {
+			p.rollback(ctx)
+			createLog("gateway %s not created%s:\n", name, dryRunSuffix())
+			p.printReport(createLogWriter())
+			return err
+		}
+	}
+
+	createLog("gateway %s created%s:\n", name, dryRunSuffix())
+	p.printReport(createLogWriter())
+
+	// A dry-run GWProxy doesn't exist so it'll never get an address.
+	if isDryRun() || timeout == 0 {
+		return nil
+	}
+
+	ep, err := waitForProxyAddress(ctx, cl, &proxy, timeout)
+	if err != nil {
+		return err
+	}
+	createLog("IP address: %s\n", ep.Targets[0])
+	createLog("DNS name: %s\n", ep.DNSName)
+
+	return nil
+}
+
+// warnEmptyClusters warns about each endpoint cluster that rules
+// send traffic to but that has no endpoints, i.e., no
+// GWEndpointSlices refer to it. That's not an error since the
+// endpoints can be added later, but until then requests that go to
+// that cluster will fail.
+func warnEmptyClusters(ctx context.Context, cl crclient.Client, account string, rules []v1alpha2.HTTPRouteRule) error {
+	slices := epicv1.GWEndpointSliceList{}
+	if err := cl.List(ctx, &slices, crclient.InNamespace(epicv1.AccountNamespace(account))); err != nil {
+		return err
+	}
+	clusters := map[string]bool{}
+	for _, slice := range slices.Items {
+		clusters[slice.Spec.ParentRef.UID] = true
+	}
+
+	warned := map[string]bool{}
+	for _, rule := range rules {
+		for _, backend := range rule.BackendRefs {
+			clusterName := string(backend.Name)
+			if clusters[clusterName] || warned[clusterName] {
+				continue
+			}
+			createLog("warning: cluster %s has no endpoints so the gateway has no backends there, add some with \"epicctl create ad-hoc-endpoint --cluster-name %s\"\n", clusterName, clusterName)
+			warned[clusterName] = true
+		}
+	}
+
+	return nil
+}
+
+// tlsSecret generates the k8s Secret that holds an ad-hoc Gateway's
+// certificate and key, which are read from certFile and keyFile.
+func tlsSecret(name string, ns string, account string, certFile string, keyFile string) (v1.Secret, error) {
+	secObj, err := versioned.SecretForTLSGeneratorV1{
+		Name: name,
+		Cert: certFile,
+		Key:  keyFile,
+	}.StructuredGenerate()
+	if err != nil {
+		return v1.Secret{}, err
+	}
+	secret := secObj.(*v1.Secret)
+	secret.Namespace = ns
+	secret.Labels = map[string]string{
+		epicv1.OwningAccountLabel: account,
+	}
+
+	return *secret, nil
+}
+
+// adHocProxy builds the GWProxy for an ad-hoc Gateway.
+func adHocProxy(account string, name string, serviceGroup string, listeners []v1alpha2.Listener) epicv1.GWProxy {
+	return epicv1.GWProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: epicv1.AccountNamespace(account),
@@ -78,33 +276,31 @@ func createAdHocGateway(ctx context.Context, cl crclient.Client, account string,
 			},
 			DisplayName: name, // Used in the DNS name
 			Gateway: v1alpha2.GatewaySpec{
-				Listeners: []v1alpha2.Listener{{
-					Protocol: v1alpha2.HTTPProtocolType,
-					Port:     portNum,
-					Name:     "http",
-				}},
+				Listeners: listeners,
 			},
 		},
 	}
+}
 
-	// Create the GWProxy.
-
-	if err := cl.Create(ctx, &proxy); err != nil {
-		return err
-	}
+// adHocRoute builds the GWRoute that links an ad-hoc Gateway with a
+// single HTTP listener (see httpListener) to the endpoint cluster
+// clusterName.
+func adHocRoute(account string, name string, port int32, clusterName string) epicv1.GWRoute {
+	sections := []v1alpha2.SectionName{httpListener(port).Name}
+	return adHocHTTPRoute(account, name, name, sections, nil, []v1alpha2.HTTPRouteRule{routerule.Backend(clusterName, port)})
+}
 
-	// Poll until the GWProxy object gets its external address/name.
-	for len(proxy.Spec.Endpoints) == 0 {
-		time.Sleep(1 * time.Second)
-		err := cl.Get(ctx, crclient.ObjectKeyFromObject(&proxy), &proxy)
-		if err != nil {
-			continue
-		}
+// adHocHTTPRoute builds a GWRoute called name that links the
+// listeners called sections on the ad-hoc Gateway gateway to the
+// backends in rules. If hostnames isn't empty then the route only
+// handles requests for those hostnames.
+func adHocHTTPRoute(account string, name string, gateway string, sections []v1alpha2.SectionName, hostnames []string, rules []v1alpha2.HTTPRouteRule) epicv1.GWRoute {
+	var routeHostnames []v1alpha2.Hostname
+	for _, hostname := range hostnames {
+		routeHostnames = append(routeHostnames, v1alpha2.Hostname(hostname))
 	}
-	fmt.Printf("IP address: %s\n", proxy.Spec.Endpoints[0].Targets[0])
-	fmt.Printf("DNS name: %s\n", proxy.Spec.Endpoints[0].DNSName)
 
-	route := epicv1.GWRoute{
+	return epicv1.GWRoute{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: epicv1.AccountNamespace(account),
@@ -115,28 +311,52 @@ func createAdHocGateway(ctx context.Context, cl crclient.Client, account string,
 		Spec: epicv1.GWRouteSpec{
 			HTTP: &v1alpha2.HTTPRouteSpec{
 				CommonRouteSpec: v1alpha2.CommonRouteSpec{
-					ParentRefs: []v1alpha2.ParentReference{{
-						Name: v1alpha2.ObjectName(name), // Link the Route to the Proxy
-					}},
+					ParentRefs: listenerRefs(gateway, sections),
 				},
-				Rules: []v1alpha2.HTTPRouteRule{{
-					BackendRefs: []v1alpha2.HTTPBackendRef{{
-						BackendRef: v1alpha2.BackendRef{
-							BackendObjectReference: v1alpha2.BackendObjectReference{
-								Name: v1alpha2.ObjectName("linux-nodes"), // Link the Route to the backend cluster
-								Port: &portNum,
-							},
-						},
-					}},
-				}},
+				Hostnames: routeHostnames,
+				Rules:     rules,
 			},
 		},
 	}
+}
 
-	// Create the GWRoute.
-	if err := cl.Create(ctx, &route); err != nil {
-		return err
+// adHocTLSRoute builds a GWRoute called name that passes the TLS
+// traffic from the listeners called sections on the ad-hoc Gateway
+// gateway through to backends.
+func adHocTLSRoute(account string, name string, gateway string, sections []v1alpha2.SectionName, backends []v1alpha2.HTTPBackendRef) epicv1.GWRoute {
+	rule := v1alpha2.TLSRouteRule{}
+	for _, backend := range backends {
+		rule.BackendRefs = append(rule.BackendRefs, backend.BackendRef)
 	}
 
-	return nil
+	return epicv1.GWRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: epicv1.AccountNamespace(account),
+			Labels: map[string]string{
+				epicv1.OwningAccountLabel: account,
+			},
+		},
+		Spec: epicv1.GWRouteSpec{
+			TLS: &v1alpha2.TLSRouteSpec{
+				CommonRouteSpec: v1alpha2.CommonRouteSpec{
+					ParentRefs: listenerRefs(gateway, sections),
+				},
+				Rules: []v1alpha2.TLSRouteRule{rule},
+			},
+		},
+	}
+}
+
+// listenerRefs links a route to the listeners called sections on the
+// ad-hoc Gateway gateway.
+func listenerRefs(gateway string, sections []v1alpha2.SectionName) []v1alpha2.ParentReference {
+	refs := []v1alpha2.ParentReference{}
+	for i := range sections {
+		refs = append(refs, v1alpha2.ParentReference{
+			Name:        v1alpha2.ObjectName(gateway), // Link the Route to the Proxy
+			SectionName: &sections[i],
+		})
+	}
+	return refs
 }
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"epic-gateway.org/epicctl/internal/routerule"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+func init() {
+	var (
+		rules     []string
+		hostnames []string
+	)
+
+	// Set up this command and hook it into its parent, the create
+	// command.
+	cmd := cobra.Command{
+		Use:   "ad-hoc-route name gateway",
+		Short: "Create ad-hoc Gateway route",
+		Long: `Create a route that sends traffic from an ad-hoc Gateway to ad-hoc endpoint clusters.
+
+"create ad-hoc-gateway" makes a route that sends all of the Gateway's
+traffic to one endpoint cluster. This command adds routes that send
+different requests to different clusters. Each --rule is a
+comma-separated list of key=value terms:
+
+  prefix=/api          match paths that start with /api
+  path=/login          match the path /login exactly
+  header=Name:value    match requests whose Name header is value
+  backend=cluster[:N]  send matching requests to an endpoint cluster
+
+A rule has at most one prefix or path, any number of headers, and at
+least one backend. If a rule has more than one backend then the
+weights (N, default 1) split the traffic between them. A backslash
+makes the next character literal so paths and header values can
+contain commas, e.g., 'header=Accept:a\,b' (quoted for the shell).
+For example, to send API requests to the "api-nodes" cluster and to
+send 10% of everything else to a canary cluster:
+
+  epicctl create ad-hoc-route web-rules web \
+    --rule prefix=/api,backend=api-nodes \
+    --rule prefix=/,backend=linux-nodes:90,backend=canary-nodes:10
+
+If --hostname is given then the route only handles requests for
+those hostnames.
+
+Arguments:
+ name - the route's name (must be unique within your account)
+ gateway - the name of the ad-hoc Gateway to which the route belongs
+`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, hostname := range hostnames {
+				if err := routerule.ValidateHostname(hostname); err != nil {
+					return err
+				}
+			}
+
+			// We'll need a Client to interact with the Epic cluster.
+			cl, err := getCreateClient()
+			if err != nil {
+				return err
+			}
+
+			return createAdHocRoute(rootCmd.Context(), cl, accountName, args[0], args[1], hostnames, rules)
+		},
+	}
+	cmd.Flags().StringArrayVar(&rules, "rule", []string{}, "a routing rule, e.g., prefix=/api,backend=api-nodes (can be repeated, escape commas in values with \\)")
+	cmd.Flags().StringArrayVar(&hostnames, "hostname", []string{}, "a hostname that the route handles (can be repeated)")
+	cmd.MarkFlagRequired("rule")
+	createCmd.AddCommand(&cmd)
+}
+
+// createAdHocRoute creates a GWRoute that attaches to the ad-hoc
+// Gateway gateway and routes its traffic using rules.
+func createAdHocRoute(ctx context.Context, cl crclient.Client, account string, name string, gateway string, hostnames []string, rules []string) error {
+	// The backends receive traffic on the Gateway's HTTP port so we
+	// need to look at its listeners.
+	proxy := epicv1.GWProxy{}
+	if err := cl.Get(ctx, crclient.ObjectKey{Namespace: epicv1.AccountNamespace(account), Name: gateway}, &proxy); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("gateway %s not found in account %s", gateway, account)
+		}
+		return err
+	}
+	sections, port := routeListeners(proxy.Spec.Gateway.Listeners, v1alpha2.HTTPProtocolType, v1alpha2.HTTPSProtocolType)
+	if len(sections) == 0 {
+		return fmt.Errorf("gateway %s has no http or https listeners", gateway)
+	}
+
+	routeRules := []v1alpha2.HTTPRouteRule{}
+	for _, rule := range rules {
+		routeRule, err := routerule.Parse(rule, port)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rule, err)
+		}
+		routeRules = append(routeRules, routeRule)
+	}
+
+	if err := warnEmptyClusters(ctx, cl, account, routeRules); err != nil {
+		return err
+	}
+
+	route := adHocHTTPRoute(account, name, gateway, sections, hostnames, routeRules)
+
+	// Create the GWRoute.
+	if err := cl.Create(ctx, &route); err != nil {
+		return err
+	}
+
+	createLog("route %s created%s\n", name, dryRunSuffix())
+
+	return nil
+}
@@ -18,6 +18,7 @@ const (
 	gitlabRegistryHostname = "registry.gitlab.com"
 	contourSecretName      = "api-users"
 	contourRealmName       = "epicauth"
+	contourRealmAnnotation = "projectcontour.io/auth-realm"
 )
 
 func init() {
@@ -34,10 +35,14 @@ team are configured and managed within a User Namespace.
 
 This command creates a User Namespace. The name can contain only
 alphanumeric characters and the dash "-". Contact Acnodal support
-for your registry-user and registry-password.`,
+for your registry-user and registry-password.
+
+This command can be re-run safely: objects that already exist are
+adopted. If a step fails then the objects that were created by that
+run are deleted.`,
 		Args: cobra.ExactArgs(3),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			client, err := getCRClient()
+			client, err := getCreateClient()
 			if err != nil {
 				panic(err.Error())
 			}
@@ -52,53 +57,101 @@ for your registry-user and registry-password.`,
 // infratructure for various purposes. This sets up the minimal
 // infrastructure that's always needed like an Account CR and the
 // various secrets needed for Docker and Contour.
+//
+// Creation is idempotent: objects that already exist are adopted so
+// a failed run can be repeated. If a step fails then the objects
+// that this run created are deleted.
 func createUserNamespace(ctx context.Context, cl client.Client, orgName string, registryUserName string, registryPassword string) error {
+	objs, err := userNamespaceObjects(orgName, registryUserName, registryPassword)
+	if err != nil {
+		return err
+	}
+
+	p := newProvisioner(cl)
+	for _, obj := range objs {
+		if err := p.ensure(ctx, obj, adoptUserNamespaceObject); err != nil {
+			p.rollback(ctx)
+			createLog("user namespace %s not created%s:\n", orgName, dryRunSuffix())
+			p.printReport(createLogWriter())
+			return err
+		}
+	}
 
+	createLog("user namespace %s created%s:\n", orgName, dryRunSuffix())
+	p.printReport(createLogWriter())
+
+	return nil
+}
+
+// userNamespaceObjects builds the objects that make up a user
+// namespace, in the order in which they need to be created.
+func userNamespaceObjects(orgName string, registryUserName string, registryPassword string) ([]client.Object, error) {
 	nsName := epicv1.AccountNamespace(orgName)
+	ns := userNamespace(orgName)
+	acct := userAccount(orgName)
+
+	// We use a private Docker registry for our proprietary images so
+	// every user NS needs a k8s secret containing credentials to access
+	// that registry.
+	secret, err := dockerSecret(gitlabSecretName, nsName, gitlabRegistryHostname, registryUserName, registryPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	pwObj, err := contourSecret(contourSecretName, nsName, contourRealmName)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create the k8s Namespace
-	ns := v1.Namespace{
+	return []client.Object{&ns, &acct, &secret, &pwObj}, nil
+}
+
+// userNamespace builds the k8s Namespace for the user namespace
+// orgName.
+func userNamespace(orgName string) v1.Namespace {
+	return v1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   nsName,
+			Name:   epicv1.AccountNamespace(orgName),
 			Labels: epicv1.UserNSLabels,
 		},
 	}
-	if err := cl.Create(ctx, &ns); err != nil {
-		return err
-	}
+}
 
-	// Every EPIC user namespace has an Account CR. Create that.
-	acct := epicv1.Account{
+// userAccount builds the Account CR that every EPIC user namespace
+// has.
+func userAccount(orgName string) epicv1.Account {
+	return epicv1.Account{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: nsName,
+			Namespace: epicv1.AccountNamespace(orgName),
 			Name:      orgName,
 		},
 		Spec: epicv1.AccountSpec{},
 	}
-	if err := cl.Create(ctx, &acct); err != nil {
-		return err
-	}
+}
 
-	// We use a private Docker registry for our proprietary images so
-	// every user NS needs a k8s secret containing credentials to access
-	// that registry.
-	secret, err := dockerSecret(gitlabSecretName, nsName, gitlabRegistryHostname, registryUserName, registryPassword)
-	if err != nil {
-		return err
-	}
-	if err := cl.Create(ctx, &secret); err != nil {
-		return err
+// adoptUserNamespaceObject brings an existing user namespace object
+// up to date. It returns true if it changed the object. The only
+// thing that we fix is the Namespace's labels, since without them
+// EPIC doesn't treat it as a user namespace. We leave the secrets
+// alone so we don't clobber existing credentials or API users.
+func adoptUserNamespaceObject(existing client.Object) bool {
+	ns, ok := existing.(*v1.Namespace)
+	if !ok {
+		return false
 	}
 
-	pwObj, err := contourSecret(contourSecretName, nsName, contourRealmName)
-	if err != nil {
-		return err
+	changed := false
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
 	}
-	if err := cl.Create(ctx, &pwObj); err != nil {
-		return err
+	for k, v := range epicv1.UserNSLabels {
+		if ns.Labels[k] != v {
+			ns.Labels[k] = v
+			changed = true
+		}
 	}
 
-	return nil
+	return changed
 }
 
 // dockerSecret generates a k8s Secret to allow k8s to access our
@@ -125,8 +178,8 @@ func contourSecret(name string, ns string, realm string) (v1.Secret, error) {
 			Name:      name,
 			Namespace: ns,
 			Annotations: map[string]string{
-				"projectcontour.io/auth-type":  "basic",
-				"projectcontour.io/auth-realm": realm,
+				"projectcontour.io/auth-type": "basic",
+				contourRealmAnnotation:        realm,
 			},
 		},
 	}
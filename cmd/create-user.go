@@ -3,97 +3,94 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
-	"strings"
 	"syscall"
+	"time"
 
-	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/term"
 
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	epicv1 "epic-gateway.org/resource-model/api/v1"
+	"epic-gateway.org/epicctl/internal/htpasswd"
 )
 
 func init() {
-	createCmd.AddCommand(&cobra.Command{
+	pwOpts := passwordOptions{}
+
+	cmd := &cobra.Command{
 		Use:     "api-user username user-namespace ",
 		Aliases: []string{"api-user", "api-users"},
 		Short:   "Create api-users",
-		Long:    `Create api-user username in a specified user namespace`,
-		Args:    cobra.ExactArgs(2),
+		Long: `Create api-user username in a specified user namespace
+
+By default the password is read from the terminal. For scripts and
+CI, the password can instead be read from stdin (--password-stdin)
+or a file (--password-file), a pre-computed bcrypt hash can be
+provided (--password-hash), or a random password can be generated
+(--generate) and printed once.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cl, err := getCRClient()
+			if err := pwOpts.validate(); err != nil {
+				return err
+			}
+
+			cl, err := getCreateClient()
 			if err != nil {
 				return err
 			}
 
-			return createAPIUser(rootCmd.Context(), cl, args[0], args[1])
+			return createAPIUser(rootCmd.Context(), cl, args[0], args[1], &pwOpts)
 		},
-	})
+	}
+	pwOpts.addFlags(cmd)
+	createCmd.AddCommand(cmd)
 }
 
-// getAPIUser extracts and the api-usernames from the api-users secret it the
-// user namespace
-
-func createAPIUser(ctx context.Context, cl client.Client, apiUser string, accountName string) error {
+// createAPIUser adds apiUser to the api-users secret in the user
+// namespace. The password comes from the source in pwOpts.
+func createAPIUser(ctx context.Context, cl client.Client, apiUser string, accountName string, pwOpts *passwordOptions) error {
+	if err := htpasswd.ValidateUser(apiUser); err != nil {
+		return err
+	}
 
-	secret := v1.Secret{}
-	err := cl.Get(ctx, client.ObjectKey{Namespace: epicv1.AccountNamespace(accountName), Name: contourSecretName}, &secret)
+	secret, err := getAPIUsersSecret(ctx, cl, accountName)
 	if err != nil {
-		return fmt.Errorf("user namespace %s not found\n", accountName)
+		return err
 	}
-
-	httppasswd := string(secret.Data["auth"])
-
-	apiusernames := strings.Fields(httppasswd)
-
-	for _, s := range apiusernames {
-		user := s[:strings.IndexByte(s, ':')]
-		if user == apiUser {
-			return fmt.Errorf("api-user %s exists", apiUser)
-		}
-
+	users := parseAPIUsers(secret)
+	if _, exists := users.Get(apiUser); exists {
+		return fmt.Errorf("api-user %s exists", apiUser)
 	}
 
-	fmt.Print("New Password:  ")
-	pass1, err := readPassword()
+	hash, generated, err := pwOpts.passwordHash(apiUser)
 	if err != nil {
-		panic(err.Error())
+		return err
 	}
 
-	fmt.Print("\nRetype New Password:  ")
-	pass2, err := readPassword()
+	// The user might have been added while we were reading the
+	// password so we check again.
+	err = modifyAPIUsers(ctx, cl, accountName, func(secret *v1.Secret, users *htpasswd.File) error {
+		if _, exists := users.Get(apiUser); exists {
+			return fmt.Errorf("api-user %s exists", apiUser)
+		}
+		if _, err := users.Set(apiUser, hash); err != nil {
+			return err
+		}
+		setRotated(secret, apiUser, time.Now())
+		return nil
+	})
 	if err != nil {
-		panic(err.Error())
-	}
-	fmt.Println("")
-
-	if len(pass1) < 6 {
-		fmt.Println("minimum password length 6 characters")
-		os.Exit(1)
-	}
-
-	if pass1 != pass2 {
-		fmt.Println("passwords don't match")
-		os.Exit(1)
-	}
-
-	pwBytes, _ := bcrypt.GenerateFromPassword([]byte(pass2), bcrypt.DefaultCost)
-
-	newapiuser := fmt.Sprintf("%s:%s\n", apiUser, string(pwBytes))
-
-	newhttppasswd := httppasswd + newapiuser
-
-	secret.Data["auth"] = []byte(newhttppasswd)
-
-	if err := cl.Update(ctx, &secret); err != nil {
 		return err
 	}
 
-	fmt.Printf("api-user %s in user-namespace %s created\n", apiUser, accountName)
+	createLog("api-user %s in user-namespace %s created%s\n", apiUser, accountName, dryRunSuffix())
+	if generated != "" && isDryRun() {
+		createLog("a password would have been generated but nothing was stored\n")
+	} else if generated != "" {
+		createLog("generated password (it won't be shown again): %s\n", generated)
+	}
 
 	return nil
 }
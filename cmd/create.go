@@ -4,16 +4,46 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var accountName string
+var (
+	accountName string
+
+	// dryRun is the value of the "--dry-run" flag.
+	dryRun string
+
+	// createClient is the client that the create subcommand used, if
+	// any. It remembers the objects that were written so we can
+	// output them.
+	createClient *recordingClient
+)
 
 // createCmd represents the create command
 var createCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Creates resources",
-	Long:  `Creates resources in EPIC.`,
+	Long: `Creates resources in EPIC.
+
+Use --dry-run=client to see what would be created without changing
+the cluster: epicctl still reads from the cluster, e.g., to check that
+the objects that the new ones refer to exist, but it doesn't write
+anything. Use --dry-run=server to also have the cluster validate the
+objects without saving them. Use -o yaml or -o json to output the
+objects.`,
+	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+		if createClient == nil {
+			return nil
+		}
+
+		format, err := createOutput()
+		if err != nil {
+			return err
+		}
+		return createClient.printObjects(format)
+	},
 }
 
 func init() {
 	createCmd.PersistentFlags().StringVar(&accountName, "account-name", "root", "name of the user account")
+	createCmd.PersistentFlags().StringVar(&dryRun, "dry-run", dryRunNone, "none|client|server: if client, read from the cluster but only print the objects that would be created; if server, also have the cluster validate them")
+	createCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "output format for the created objects: json|yaml")
 	rootCmd.AddCommand(createCmd)
 }
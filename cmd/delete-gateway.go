@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+func init() {
+	var (
+		account string
+		yes     bool
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:     "gateway name",
+		Aliases: []string{"gw"},
+		Short:   "Delete gateway",
+		Long: `Delete an ad-hoc Gateway.
+
+The Gateway's routes are deleted along with it, as is its HTTPS
+certificate Secret if "create ad-hoc-gateway" made one. The endpoint
+clusters are left alone since other Gateways might use them. The
+routes are deleted first, and must be gone before the Gateway is
+deleted.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			return deleteGateway(rootCmd.Context(), cl, account, args[0], yes, timeout)
+		},
+	}
+	cmd.Flags().StringVar(&account, "account-name", "root", "name of the user account")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "don't ask for confirmation")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "how long to wait for each group of objects to be deleted")
+	deleteCmd.AddCommand(cmd)
+}
+
+// deleteGateway implements the "delete gateway" command.
+func deleteGateway(ctx context.Context, cl client.Client, account string, name string, yes bool, timeout time.Duration) error {
+	stages, err := gatewayStages(ctx, cl, account, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Gateway %s consists of:\n", name)
+	for _, stage := range stages {
+		for _, obj := range stage.Objects {
+			fmt.Printf("  %s %s\n", stage.Kind, obj.GetName())
+		}
+	}
+
+	if !yes {
+		ok, err := confirm(fmt.Sprintf("Delete gateway %s?", name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("gateway %s not deleted", name)
+		}
+	}
+
+	for _, stage := range stages {
+		for _, obj := range stage.Objects {
+			if err := cl.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("deleting %s %s: %w", stage.Kind, obj.GetName(), err)
+			}
+		}
+
+		if stuck := waitForDeletion(ctx, cl, stage.Objects, timeout); len(stuck) > 0 {
+			for _, obj := range stuck {
+				fmt.Printf("%s %s is stuck, finalizers: %v\n", stage.Kind, obj.GetName(), obj.GetFinalizers())
+			}
+			return fmt.Errorf("%d %s objects were not deleted", len(stuck), stage.Kind)
+		}
+	}
+
+	fmt.Printf("gateway %s deleted\n", name)
+
+	return nil
+}
+
+// gatewayStages returns the objects that make up the ad-hoc Gateway
+// name, grouped in the order in which they need to be deleted. Routes
+// refer to the GWProxy so they go first, and the certificate Secret
+// goes last so the GWProxy never refers to a missing Secret.
+func gatewayStages(ctx context.Context, cl client.Client, account string, name string) ([]teardownStage, error) {
+	proxy, err := getProxy(ctx, cl, account, name)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := gatewayRoutes(ctx, cl, account, name)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := []teardownStage{
+		{Kind: "GWRoute"},
+		{Kind: "GWProxy", Objects: []client.Object{&proxy}},
+		{Kind: "Secret"},
+	}
+	for i := range routes {
+		stages[0].Objects = append(stages[0].Objects, &routes[i])
+	}
+
+	// Only delete the certificate Secret if it's the one that "create
+	// ad-hoc-gateway" made for this Gateway.
+	secret := v1.Secret{}
+	err = cl.Get(ctx, client.ObjectKey{Namespace: proxy.Namespace, Name: name + "-tls"}, &secret)
+	switch {
+	case err == nil:
+		if secret.Type == v1.SecretTypeTLS && secret.Labels[epicv1.OwningAccountLabel] == account {
+			stages[2].Objects = append(stages[2].Objects, &secret)
+		}
+	case !apierrors.IsNotFound(err):
+		return nil, err
+	}
+
+	return stages, nil
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+// teardownStage is a group of objects that can be deleted together.
+// Each stage's objects must be gone before the next stage starts.
+type teardownStage struct {
+	Kind    string
+	Objects []client.Object
+}
+
+func init() {
+	var (
+		yes     bool
+		timeout time.Duration
+	)
+
+	deleteNSCmd := &cobra.Command{
+		Use:     "user-namespace name",
+		Aliases: []string{"ns", "user-ns"},
+		Short:   "Delete User Namespace",
+		Long: `Delete an EPIC User Namespace and everything in it.
+
+The Gateways, Routes, Endpoints and Secrets in the User Namespace are
+listed and, after confirmation, deleted in dependency order. Each
+group of objects must be gone, i.e., their finalizers must have run,
+before the next group is deleted. Objects that are still present when
+the timeout expires are reported.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			return deleteUserNamespace(rootCmd.Context(), cl, args[0], yes, timeout)
+		},
+	}
+	deleteNSCmd.Flags().BoolVarP(&yes, "yes", "y", false, "don't ask for confirmation")
+	deleteNSCmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "how long to wait for each group of objects to be deleted")
+	deleteCmd.AddCommand(deleteNSCmd)
+}
+
+// deleteUserNamespace implements the "delete user-namespace"
+// command.
+func deleteUserNamespace(ctx context.Context, cl client.Client, orgName string, yes bool, timeout time.Duration) error {
+	nsName := epicv1.AccountNamespace(orgName)
+
+	ns := v1.Namespace{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: nsName}, &ns); err != nil {
+		return fmt.Errorf("user namespace %s not found", orgName)
+	}
+
+	stages, err := userNamespaceStages(ctx, cl, &ns)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("User namespace %s contains:\n", orgName)
+	for _, stage := range stages {
+		for _, obj := range stage.Objects {
+			fmt.Printf("  %s %s\n", stage.Kind, obj.GetName())
+		}
+	}
+
+	if !yes {
+		ok, err := confirm(fmt.Sprintf("Delete user namespace %s and everything in it?", orgName))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("user namespace %s not deleted", orgName)
+		}
+	}
+
+	for _, stage := range stages {
+		if len(stage.Objects) == 0 {
+			continue
+		}
+
+		for _, obj := range stage.Objects {
+			if err := cl.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("deleting %s %s: %w", stage.Kind, obj.GetName(), err)
+			}
+		}
+
+		if stuck := waitForDeletion(ctx, cl, stage.Objects, timeout); len(stuck) > 0 {
+			for _, obj := range stuck {
+				fmt.Printf("%s %s is stuck, finalizers: %v\n", stage.Kind, obj.GetName(), obj.GetFinalizers())
+			}
+			return fmt.Errorf("%d %s objects were not deleted", len(stuck), stage.Kind)
+		}
+		fmt.Printf("%s objects deleted\n", stage.Kind)
+	}
+
+	fmt.Printf("user namespace %s deleted\n", orgName)
+
+	return nil
+}
+
+// userNamespaceStages returns the objects in ns, grouped in the order
+// in which they need to be deleted. Routes refer to Gateways and
+// Endpoints so they go first, and the Namespace itself goes last.
+func userNamespaceStages(ctx context.Context, cl client.Client, ns *v1.Namespace) ([]teardownStage, error) {
+	inNS := &client.ListOptions{Namespace: ns.Name}
+
+	routes := epicv1.GWRouteList{}
+	if err := cl.List(ctx, &routes, inNS); err != nil {
+		return nil, err
+	}
+	slices := epicv1.GWEndpointSliceList{}
+	if err := cl.List(ctx, &slices, inNS); err != nil {
+		return nil, err
+	}
+	proxies := epicv1.GWProxyList{}
+	if err := cl.List(ctx, &proxies, inNS); err != nil {
+		return nil, err
+	}
+	secrets := v1.SecretList{}
+	if err := cl.List(ctx, &secrets, inNS); err != nil {
+		return nil, err
+	}
+	accounts := epicv1.AccountList{}
+	if err := cl.List(ctx, &accounts, inNS); err != nil {
+		return nil, err
+	}
+
+	stages := []teardownStage{
+		{Kind: "GWRoute"},
+		{Kind: "GWEndpointSlice"},
+		{Kind: "GWProxy"},
+		{Kind: "Secret"},
+		{Kind: "Account"},
+		{Kind: "Namespace", Objects: []client.Object{ns}},
+	}
+	for i := range routes.Items {
+		stages[0].Objects = append(stages[0].Objects, &routes.Items[i])
+	}
+	for i := range slices.Items {
+		stages[1].Objects = append(stages[1].Objects, &slices.Items[i])
+	}
+	for i := range proxies.Items {
+		stages[2].Objects = append(stages[2].Objects, &proxies.Items[i])
+	}
+	for i := range secrets.Items {
+		// Kubernetes manages the service account tokens.
+		if secrets.Items[i].Type != v1.SecretTypeServiceAccountToken {
+			stages[3].Objects = append(stages[3].Objects, &secrets.Items[i])
+		}
+	}
+	for i := range accounts.Items {
+		stages[4].Objects = append(stages[4].Objects, &accounts.Items[i])
+	}
+
+	return stages, nil
+}
+
+// waitForDeletion waits until objs are gone or timeout expires. It
+// returns the objects that are still present, updated with their
+// current state so the caller can see their finalizers.
+func waitForDeletion(ctx context.Context, cl client.Client, objs []client.Object, timeout time.Duration) []client.Object {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	remaining := objs
+	wait.PollImmediateUntilWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
+		still := []client.Object{}
+		for _, obj := range remaining {
+			err := cl.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			still = append(still, obj)
+		}
+		remaining = still
+		return len(remaining) == 0, nil
+	})
+
+	return remaining
+}
@@ -3,21 +3,21 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	epicv1 "epic-gateway.org/resource-model/api/v1"
+	"epic-gateway.org/epicctl/internal/htpasswd"
 )
 
 func init() {
 	deleteCmd.AddCommand(&cobra.Command{
 		Use:     "api-user username user-namespace ",
 		Aliases: []string{"api-user", "api-users"},
-		Short:   "Create api-users",
-		Long:    `Create api-user username in a specified user namespace`,
+		Short:   "Delete api-users",
+		Long:    `Delete api-user username from a specified user namespace`,
 		Args:    cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cl, err := getCRClient()
@@ -30,38 +30,21 @@ func init() {
 	})
 }
 
-// getAPIUser extracts and the api-usernames from the api-users secret it the
-// user namespace
-
+// deleteAPIUser removes apiUser from the api-users secret in the
+// user namespace.
 func deleteAPIUser(ctx context.Context, cl client.Client, apiUser string, accountName string) error {
-
-	var newhttppasswd string
-
-	secret := v1.Secret{}
-	err := cl.Get(ctx, client.ObjectKey{Namespace: epicv1.AccountNamespace(accountName), Name: contourSecretName}, &secret)
-	if err != nil {
-		return fmt.Errorf("user namespace %s not found\n", accountName)
-	}
-
-	httppasswd := string(secret.Data["auth"])
-
-	apiusernames := strings.Fields(httppasswd)
-
-	for _, s := range apiusernames {
-		user := s[:strings.IndexByte(s, ':')]
-		if user != apiUser {
-			newhttppasswd += s + "\n"
+	err := modifyAPIUsers(ctx, cl, accountName, func(secret *v1.Secret, users *htpasswd.File) error {
+		if !users.Delete(apiUser) {
+			return fmt.Errorf("api-user %s not found in user-namespace %s", apiUser, accountName)
 		}
-
-	}
-
-	secret.Data["auth"] = []byte(newhttppasswd)
-
-	if err := cl.Update(ctx, &secret); err != nil {
+		setRotated(secret, apiUser, time.Time{})
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
-	fmt.Printf("api-user %s in user-namespace %s deleted \n", apiUser, accountName)
+	fmt.Printf("api-user %s in user-namespace %s deleted\n", apiUser, accountName)
 
 	return nil
 }
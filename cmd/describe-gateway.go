@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"epic-gateway.org/epicctl/internal/printer"
+	"epic-gateway.org/epicctl/internal/routerule"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+// gatewayDescription is the machine-readable description of an
+// ad-hoc Gateway.
+type gatewayDescription struct {
+	gatewayInfo
+	Account   string         `json:"account"`
+	Listeners []listenerInfo `json:"listeners"`
+	Routes    []routeInfo    `json:"routes"`
+	Backends  []backendInfo  `json:"backends"`
+}
+
+// listenerInfo is the machine-readable summary of a Gateway
+// listener.
+type listenerInfo struct {
+	Name        string `json:"name"`
+	Protocol    string `json:"protocol"`
+	Port        int32  `json:"port"`
+	TLSMode     string `json:"tlsMode,omitempty"`
+	Certificate string `json:"certificate,omitempty"`
+}
+
+// routeInfo is the machine-readable summary of a GWRoute. The rules
+// are in the "create ad-hoc-route --rule" syntax.
+type routeInfo struct {
+	Name      string   `json:"name"`
+	Hostnames []string `json:"hostnames,omitempty"`
+	Rules     []string `json:"rules"`
+}
+
+// backendInfo is the machine-readable summary of an endpoint cluster
+// to which a Gateway sends traffic.
+type backendInfo struct {
+	Cluster   string         `json:"cluster"`
+	Endpoints []endpointInfo `json:"endpoints"`
+}
+
+// endpointInfo is the machine-readable summary of an ad-hoc
+// endpoint.
+type endpointInfo struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Port    int32  `json:"port,omitempty"`
+	Ready   string `json:"ready"`
+}
+
+func init() {
+	var account string
+
+	cmd := &cobra.Command{
+		Use:     "gateway name",
+		Aliases: []string{"gw"},
+		Short:   "Describes a gateway",
+		Long: `Describes an ad-hoc Gateway: its listeners, addresses, routes and
+backend endpoint clusters, and whether the endpoints are ready.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output()
+			if err != nil {
+				return err
+			}
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			return describeGateway(rootCmd.Context(), cl, account, args[0], format)
+		},
+	}
+	cmd.Flags().StringVar(&account, "account-name", "root", "name of the user account")
+	describeCmd.AddCommand(cmd)
+}
+
+// describeGateway gets an ad-hoc Gateway and the objects that it
+// uses from the cluster and prints them.
+func describeGateway(ctx context.Context, cl client.Client, account string, name string, format printer.Format) error {
+	proxy, err := getProxy(ctx, cl, account, name)
+	if err != nil {
+		return err
+	}
+	Debug(" Raw CR Contents: %+v\n", proxy)
+
+	routes, err := gatewayRoutes(ctx, cl, account, name)
+	if err != nil {
+		return err
+	}
+	slices := epicv1.GWEndpointSliceList{}
+	if err := cl.List(ctx, &slices, client.InNamespace(epicv1.AccountNamespace(account))); err != nil {
+		return err
+	}
+
+	desc := gatewayDescription{
+		gatewayInfo: proxyInfo(proxy),
+		Account:     account,
+		Listeners:   listenerInfos(proxy),
+		Routes:      routeInfos(routes),
+		Backends:    backendInfos(routes, slices.Items),
+	}
+
+	switch format {
+	case printer.Name:
+		fmt.Println(desc.Name)
+		return nil
+	case printer.JSON, printer.YAML:
+		return printer.PrintObject(os.Stdout, format, desc)
+	}
+
+	fmt.Printf("Gateway %s in account %s\n\n", desc.Name, account)
+	fmt.Printf("DNS Name: %s\n", orDash(desc.DNSName))
+	fmt.Printf("Addresses: %s\n", orDash(strings.Join(desc.Addresses, ",")))
+	fmt.Printf("Service Group: %s\n\n", orDash(desc.ServiceGroup))
+
+	listeners := printer.Table{
+		Columns: []printer.Column{
+			{Header: "Name"},
+			{Header: "Protocol"},
+			{Header: "Port"},
+			{Header: "TLS"},
+			{Header: "Certificate", Wide: true},
+		},
+	}
+	for _, l := range desc.Listeners {
+		listeners.Append(l.Name, l, l.Name, l.Protocol, strconv.Itoa(int(l.Port)), orDash(l.TLSMode), orDash(l.Certificate))
+	}
+	fmt.Printf("Listeners\n")
+	if err := listeners.Print(os.Stdout, format); err != nil {
+		return err
+	}
+
+	rules := printer.Table{
+		Columns: []printer.Column{
+			{Header: "Route"},
+			{Header: "Hostnames"},
+			{Header: "Rule"},
+		},
+	}
+	for _, r := range desc.Routes {
+		for _, rule := range r.Rules {
+			rules.Append(r.Name, r, r.Name, orDash(strings.Join(r.Hostnames, ",")), rule)
+		}
+	}
+	fmt.Printf("\nRoutes\n")
+	if err := rules.Print(os.Stdout, format); err != nil {
+		return err
+	}
+
+	endpoints := printer.Table{
+		Columns: []printer.Column{
+			{Header: "Cluster"},
+			{Header: "Endpoint"},
+			{Header: "Address"},
+			{Header: "Ready"},
+		},
+	}
+	for _, b := range desc.Backends {
+		if len(b.Endpoints) == 0 {
+			endpoints.Append(b.Cluster, b, b.Cluster, "-", "-", "no endpoints")
+		}
+		for _, ep := range b.Endpoints {
+			address := ep.Address
+			if ep.Port != 0 {
+				address = fmt.Sprintf("%s:%d", ep.Address, ep.Port)
+			}
+			endpoints.Append(b.Cluster, b, b.Cluster, ep.Name, address, ep.Ready)
+		}
+	}
+	fmt.Printf("\nBackends\n")
+	return endpoints.Print(os.Stdout, format)
+}
+
+// getProxy gets the GWProxy for the ad-hoc Gateway name.
+func getProxy(ctx context.Context, cl client.Client, account string, name string) (epicv1.GWProxy, error) {
+	proxy := epicv1.GWProxy{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: epicv1.AccountNamespace(account), Name: name}, &proxy); err != nil {
+		if apierrors.IsNotFound(err) {
+			return proxy, fmt.Errorf("gateway %s not found in account %s", name, account)
+		}
+		return proxy, err
+	}
+	return proxy, nil
+}
+
+// gatewayRoutes lists the GWRoutes that attach to the ad-hoc Gateway
+// name, i.e., the ones that "create ad-hoc-gateway" made and any that
+// "create ad-hoc-route" added.
+func gatewayRoutes(ctx context.Context, cl client.Client, account string, name string) ([]epicv1.GWRoute, error) {
+	routes := epicv1.GWRouteList{}
+	if err := cl.List(ctx, &routes, client.InNamespace(epicv1.AccountNamespace(account))); err != nil {
+		return nil, err
+	}
+
+	attached := []epicv1.GWRoute{}
+	for _, route := range routes.Items {
+		var parents []v1alpha2.ParentReference
+		switch {
+		case route.Spec.HTTP != nil:
+			parents = route.Spec.HTTP.ParentRefs
+		case route.Spec.TLS != nil:
+			parents = route.Spec.TLS.ParentRefs
+		}
+		for _, parent := range parents {
+			if string(parent.Name) == name {
+				attached = append(attached, route)
+				break
+			}
+		}
+	}
+
+	return attached, nil
+}
+
+// listenerInfos summarizes proxy's listeners.
+func listenerInfos(proxy epicv1.GWProxy) []listenerInfo {
+	infos := []listenerInfo{}
+	for _, l := range proxy.Spec.Gateway.Listeners {
+		info := listenerInfo{
+			Name:     string(l.Name),
+			Protocol: string(l.Protocol),
+			Port:     int32(l.Port),
+		}
+		if l.TLS != nil {
+			if l.TLS.Mode != nil {
+				info.TLSMode = string(*l.TLS.Mode)
+			}
+			certs := []string{}
+			for _, ref := range l.TLS.CertificateRefs {
+				certs = append(certs, string(ref.Name))
+			}
+			info.Certificate = strings.Join(certs, ",")
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// routeInfos summarizes routes.
+func routeInfos(routes []epicv1.GWRoute) []routeInfo {
+	infos := []routeInfo{}
+	for _, route := range routes {
+		info := routeInfo{Name: route.Name, Rules: []string{}}
+		var hostnames []v1alpha2.Hostname
+		switch {
+		case route.Spec.HTTP != nil:
+			hostnames = route.Spec.HTTP.Hostnames
+		case route.Spec.TLS != nil:
+			hostnames = route.Spec.TLS.Hostnames
+		}
+		for _, hostname := range hostnames {
+			info.Hostnames = append(info.Hostnames, string(hostname))
+		}
+		for _, rule := range routeRules(route) {
+			info.Rules = append(info.Rules, routerule.Format(rule))
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// routeRules returns route's rules. A TLS route's rules only have
+// backends, so they're returned as HTTP rules without matches.
+func routeRules(route epicv1.GWRoute) []v1alpha2.HTTPRouteRule {
+	switch {
+	case route.Spec.HTTP != nil:
+		return route.Spec.HTTP.Rules
+	case route.Spec.TLS != nil:
+		rules := []v1alpha2.HTTPRouteRule{}
+		for _, tlsRule := range route.Spec.TLS.Rules {
+			rule := v1alpha2.HTTPRouteRule{}
+			for _, backend := range tlsRule.BackendRefs {
+				rule.BackendRefs = append(rule.BackendRefs, v1alpha2.HTTPBackendRef{BackendRef: backend})
+			}
+			rules = append(rules, rule)
+		}
+		return rules
+	}
+	return nil
+}
+
+// backendInfos summarizes the endpoint clusters to which routes send
+// traffic, in the order in which they first appear, and the
+// endpoints in slices that belong to them.
+func backendInfos(routes []epicv1.GWRoute, slices []epicv1.GWEndpointSlice) []backendInfo {
+	infos := []backendInfo{}
+	seen := map[string]bool{}
+	for _, route := range routes {
+		for _, rule := range routeRules(route) {
+			for _, backend := range rule.BackendRefs {
+				cluster := string(backend.Name)
+				if seen[cluster] {
+					continue
+				}
+				seen[cluster] = true
+				infos = append(infos, backendInfo{Cluster: cluster, Endpoints: clusterEndpoints(cluster, slices)})
+			}
+		}
+	}
+	return infos
+}
+
+// clusterEndpoints summarizes the endpoints in slices that belong to
+// the endpoint cluster.
+func clusterEndpoints(cluster string, slices []epicv1.GWEndpointSlice) []endpointInfo {
+	infos := []endpointInfo{}
+	for _, slice := range slices {
+		if slice.Spec.ParentRef.UID != cluster {
+			continue
+		}
+
+		var port int32
+		if len(slice.Spec.Ports) > 0 && slice.Spec.Ports[0].Port != nil {
+			port = *slice.Spec.Ports[0].Port
+		}
+
+		for _, ep := range slice.Spec.Endpoints {
+			info := endpointInfo{
+				Name:    slice.Name,
+				Address: strings.Join(ep.Addresses, ","),
+				Port:    port,
+				Ready:   "unknown",
+			}
+			if ep.NodeName != nil {
+				info.Name = *ep.NodeName
+			}
+			if ep.Conditions.Ready != nil {
+				info.Ready = strconv.FormatBool(*ep.Conditions.Ready)
+			}
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
@@ -1,10 +1,11 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
@@ -13,9 +14,30 @@ import (
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"epic-gateway.org/epicctl/internal/podlog"
+	"epic-gateway.org/epicctl/internal/printer"
+
 	epicv1 "epic-gateway.org/resource-model/api/v1"
 )
 
+// userNamespaceDescription is the machine-readable description of a
+// user namespace.
+type userNamespaceDescription struct {
+	Name      string        `json:"name"`
+	Namespace string        `json:"namespace"`
+	APIUsers  []string      `json:"apiUsers"`
+	Gateways  []gatewayInfo `json:"gateways"`
+	Activity  []string      `json:"activity"`
+}
+
+// gatewayInfo is the machine-readable summary of a GWProxy.
+type gatewayInfo struct {
+	Name         string   `json:"name"`
+	DNSName      string   `json:"dnsName,omitempty"`
+	Addresses    []string `json:"addresses"`
+	ServiceGroup string   `json:"serviceGroup,omitempty"`
+}
+
 func init() {
 	describeNSCmd := &cobra.Command{
 		Use:     "user-namespace",
@@ -24,6 +46,10 @@ func init() {
 		Long:    `Describes an EPIC user namespace.`,
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output()
+			if err != nil {
+				return err
+			}
 			cs, err := getGoClientset()
 			if err != nil {
 				return err
@@ -35,7 +61,7 @@ func init() {
 
 			account := args[0]
 
-			return describeNS(context.Background(), cl, cs, account)
+			return describeNS(context.Background(), cl, cs, account, format)
 		},
 	}
 	describeCmd.AddCommand(describeNSCmd)
@@ -43,29 +69,65 @@ func init() {
 
 // describeNS gets a LoadBalancer from the cluster and dumps its contents
 // to stdout.
-func describeNS(ctx context.Context, cl client.Client, cs *kubernetes.Clientset, nsName string) error {
+func describeNS(ctx context.Context, cl client.Client, cs *kubernetes.Clientset, nsName string, format printer.Format) error {
 	var (
 		err  error
 		acct epicv1.Account
+		desc = userNamespaceDescription{
+			Name:      nsName,
+			Namespace: epicv1.AccountNamespace(nsName),
+			Gateways:  []gatewayInfo{},
+		}
 	)
 
 	if acct, err = getAccount(ctx, cl, nsName); err != nil {
 		return err
 	}
+	Debug(" Raw CR Contents: %+v\n", acct)
+
+	// A section that can't be read is left out (with a warning) so the
+	// rest of the description is still useful.
+	if desc.APIUsers, err = getAPIUsers(ctx, cl, nsName); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: can't read the api-users: %s\n", err)
+	}
+
+	proxies, err := getProxies(ctx, cl, nsName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: can't read the gateways: %s\n", err)
+	}
+	gateways := proxyTable(proxies)
+	for _, row := range gateways.Rows {
+		desc.Gateways = append(desc.Gateways, row.Object.(gatewayInfo))
+	}
+
+	if desc.Activity, err = getActivity(ctx, cs, nsName); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: can't read the web service activity: %s\n", err)
+	}
+
+	switch format {
+	case printer.Name:
+		fmt.Println(desc.Name)
+		return nil
+	case printer.JSON, printer.YAML:
+		return printer.PrintObject(os.Stdout, format, desc)
+	}
 
 	// NS Info
-	Debug(" Raw CR Contents: %+v\n", acct)
 	fmt.Printf("EPIC User Namespace %s\n\n", nsName)
 
 	fmt.Printf("API Users\n")
-	listAPIUsers(ctx, cl, nsName)
+	for _, user := range desc.APIUsers {
+		fmt.Printf("  %s\n", user)
+	}
 
 	fmt.Printf("Gateways\n")
-	showProxies(ctx, cl, nsName)
+	if err = gateways.Print(os.Stdout, format); err != nil {
+		return err
+	}
 
 	fmt.Printf("Web Service Activity\n")
-	if err = showActivity(ctx, cs, nsName); err != nil {
-		return err
+	for _, line := range desc.Activity {
+		fmt.Println(line)
 	}
 
 	return nil
@@ -75,58 +137,126 @@ func getAccount(ctx context.Context, cl client.Client, accountName string) (acct
 	return acct, cl.Get(ctx, client.ObjectKey{Namespace: epicv1.AccountNamespace(accountName), Name: accountName}, &acct)
 }
 
-func showProxies(ctx context.Context, cl client.Client, accountName string) error {
+// getProxies lists the GWProxies in the user namespace.
+func getProxies(ctx context.Context, cl client.Client, accountName string) ([]epicv1.GWProxy, error) {
 	proxies := epicv1.GWProxyList{}
 	err := cl.List(ctx, &proxies, &client.ListOptions{Namespace: epicv1.AccountNamespace(accountName)})
 	if err != nil {
-		return fmt.Errorf("user namespace %s not found", accountName)
+		return nil, fmt.Errorf("user namespace %s not found", accountName)
 	}
 
-	for _, p := range proxies.Items {
-		fmt.Printf("  %s\n", p.Name)
+	return proxies.Items, nil
+}
+
+// proxyTable builds an output table that summarizes proxies.
+func proxyTable(proxies []epicv1.GWProxy) printer.Table {
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "Name"},
+			{Header: "DNS Name"},
+			{Header: "Addresses"},
+			{Header: "Service Group", Wide: true},
+		},
 	}
 
-	return nil
+	for _, p := range proxies {
+		info := proxyInfo(p)
+		table.Append(info.Name, info,
+			info.Name,
+			info.DNSName,
+			strings.Join(info.Addresses, ","),
+			info.ServiceGroup,
+		)
+	}
+
+	return table
+}
+
+// proxyInfo summarizes proxy.
+func proxyInfo(proxy epicv1.GWProxy) gatewayInfo {
+	info := gatewayInfo{
+		Name:         proxy.Name,
+		Addresses:    []string{},
+		ServiceGroup: proxy.Labels[epicv1.OwningLBServiceGroupLabel],
+	}
+	for _, ep := range proxy.Spec.Endpoints {
+		if info.DNSName == "" {
+			info.DNSName = ep.DNSName
+		}
+		info.Addresses = append(info.Addresses, ep.Targets...)
+	}
+	return info
 }
 
-func showActivity(ctx context.Context, cs *kubernetes.Clientset, accountName string) error {
+// getActivity returns the recent web service log lines that refer
+// to the user namespace, from all of the web service replicas, in
+// timestamp order.
+func getActivity(ctx context.Context, cs *kubernetes.Clientset, accountName string) ([]string, error) {
+	lines := []string{}
+
 	podLogOptions := v1.PodLogOptions{
 		SinceSeconds: pointer.Int64Ptr(300),
 		Timestamps:   true,
 	}
 
-	wsPod, err := getWebServicePod(ctx, cs)
+	wsPods, err := getWebServicePods(ctx, cs)
 	if err != nil {
-		return err
+		return lines, err
 	}
 
-	podLogRequest := cs.CoreV1().
-		Pods(wsPod.Namespace).GetLogs(wsPod.Name, &podLogOptions)
-	stream, err := podLogRequest.Stream(ctx)
+	// A web service replica that hasn't started has no activity.
+	wsSources, _ := podLogSources(wsPods)
+	merger := podlog.Merger{}
+	err = streamLogs(ctx, cs, wsSources, podLogOptions, accountLineFilter(accountName, func(line podlog.Line) {
+		merger.Add(line, time.Time{})
+	}))
 	if err != nil {
-		return err
+		return lines, err
 	}
-	defer stream.Close()
 
-	scanner := bufio.NewScanner(stream)
-	for scanner.Scan() {
-		if line := scanner.Text(); strings.Contains(line, accountName) {
-			fmt.Println(line)
+	for _, line := range merger.Flush() {
+		lines = append(lines, formatActivity(line))
+	}
+
+	return lines, nil
+}
+
+// accountLineFilter returns a streamLogs callback that parses each
+// timestamped line and passes the ones that refer to the account to
+// fn.
+func accountLineFilter(accountName string, fn func(podlog.Line)) func(logSource, string) {
+	namespace := epicv1.AccountNamespace(accountName)
+
+	return func(src logSource, raw string) {
+		line, err := podlog.ParseTimestamped(src.Pod, raw)
+		if err != nil {
+			Debug("%s: %s\n", src, err)
+			return
+		}
+		if podlog.MatchesAccount(line.Text, accountName, namespace) {
+			fn(line)
 		}
 	}
+}
 
-	return nil
+// formatActivity formats a web service log line for output.
+func formatActivity(line podlog.Line) string {
+	return fmt.Sprintf("%s [%s] %s", line.Time.Format(time.RFC3339Nano), line.Source, line.Text)
 }
 
-func getWebServicePod(ctx context.Context, cs *kubernetes.Clientset) (pod v1.Pod, err error) {
-	pods, err := cs.CoreV1().Pods("epic").List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=epic,app.kubernetes.io/component=web-service"})
+// webServiceSelector selects the pods that run the EPIC web service.
+const webServiceSelector = "app.kubernetes.io/name=epic,app.kubernetes.io/component=web-service"
+
+// getWebServicePods returns the pods that run the EPIC web service.
+func getWebServicePods(ctx context.Context, cs *kubernetes.Clientset) ([]v1.Pod, error) {
+	pods, err := cs.CoreV1().Pods("epic").List(ctx, metav1.ListOptions{LabelSelector: webServiceSelector})
 	if err != nil {
-		return pod, err
+		return nil, err
 	}
 
 	if len(pods.Items) < 1 {
-		return pod, fmt.Errorf("web service pod not found")
+		return nil, fmt.Errorf("web service pod not found")
 	}
 
-	return pods.Items[0], nil
+	return pods.Items, nil
 }
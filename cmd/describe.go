@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+
+	"epic-gateway.org/epicctl/internal/printer"
 )
 
 func init() {
+	describeCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", printer.FlagUsage)
 	rootCmd.AddCommand(describeCmd)
 }
 
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	"epic-gateway.org/epicctl/internal/archive"
+	"epic-gateway.org/epicctl/internal/tenant"
+	"epic-gateway.org/epicctl/internal/textdiff"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+// serverManagedFields are the metadata fields that the API server
+// maintains. They're noise in a diff.
+var serverManagedFields = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"managedFields",
+	"selfLink",
+}
+
+func init() {
+	var (
+		specFile       string
+		passphraseFile string
+	)
+
+	diffCmd := &cobra.Command{
+		Use:   "diff -f tenant.yaml|archive.tar.gz",
+		Short: "Compares a tenant spec or an export with the cluster",
+		Long: `Compares a tenant spec, or a user namespace archive made by "epicctl
+export", with the live objects in the cluster.
+
+Each object in the spec or archive is compared with its live
+counterpart and the differences are printed as a unified diff. The
+cluster is asked to dry-run the apply so the comparison includes the
+server's defaults, i.e., it shows what "epicctl apply" would change.
+Fields that the server manages and object status are ignored, and
+Secret data is redacted.
+
+Gateways, routes and endpoints that exist in the cluster but not in
+the spec or archive are listed but not diffed since apply doesn't
+delete them.
+
+If the archive is encrypted then the passphrase is read from the
+terminal or --passphrase-file.
+
+The exit code is non-zero if any objects differ.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			orgName, objs, err := diffSource(rootCmd.Context(), cl, specFile, passphraseFile)
+			if err != nil {
+				return err
+			}
+
+			return diffTenant(rootCmd.Context(), cl, orgName, objs)
+		},
+	}
+	diffCmd.Flags().StringVarP(&specFile, "filename", "f", "", "the tenant spec file or user namespace archive")
+	diffCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "read the archive's passphrase from this file instead of the terminal")
+	diffCmd.MarkFlagRequired("filename")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// diffSource reads the objects to compare from file, which is either
+// a tenant spec or an archive made by "epicctl export". It returns
+// the name of the user namespace and its objects.
+func diffSource(ctx context.Context, cl client.Client, file string, passphraseFile string) (string, []client.Object, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Archives are gzipped tarballs, which might be encrypted.
+	if archive.IsSealed(data) || bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		entries, err := readArchive(file, passphraseFile)
+		if err != nil {
+			return "", nil, fmt.Errorf("reading %s: %w", file, err)
+		}
+		orgName, objs, err := parseArchive(entries)
+		if err != nil {
+			return "", nil, fmt.Errorf("reading %s: %w", file, err)
+		}
+		return orgName, objs, nil
+	}
+
+	spec, err := tenant.Parse(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+	objs, err := tenantObjects(ctx, cl, spec)
+	return spec.Name, objs, err
+}
+
+// diffTenant prints the differences between objs and the live
+// objects in the user namespace orgName.
+func diffTenant(ctx context.Context, cl client.Client, orgName string, objs []client.Object) error {
+	differ := 0
+	desired := map[string]bool{}
+
+	for _, obj := range objs {
+		desired[describeObject(obj)] = true
+
+		diff, err := diffObject(ctx, cl, obj)
+		if err != nil {
+			return fmt.Errorf("comparing %s: %w", describeObject(obj), err)
+		}
+		if diff != "" {
+			differ++
+			fmt.Print(diff)
+		}
+	}
+
+	extra, err := unmanagedObjects(ctx, cl, epicv1.AccountNamespace(orgName), desired)
+	if err != nil {
+		return err
+	}
+	if len(extra) > 0 {
+		fmt.Println("\nIn the cluster but not in the spec:")
+		for _, name := range extra {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+
+	if differ > 0 {
+		return fmt.Errorf("%d objects differ", differ)
+	}
+
+	fmt.Println("No differences found")
+
+	return nil
+}
+
+// diffObject returns the unified diff between the live version of
+// obj and what the live version would be after obj is applied. If
+// they're the same then it returns "".
+func diffObject(ctx context.Context, cl client.Client, obj client.Object) (string, error) {
+	name := describeObject(obj)
+	patch, err := applyConfiguration(obj)
+	if err != nil {
+		return "", err
+	}
+
+	var live client.Object = obj.DeepCopyObject().(client.Object)
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		live = nil
+	}
+
+	// A dry-run apply fails if the object's namespace doesn't exist
+	// yet. In that case the object doesn't exist either, so the spec
+	// is as close as we can get.
+	var merged client.Object = patch.DeepCopy()
+	if err := cl.Patch(ctx, merged, client.Apply, client.ForceOwnership, client.FieldOwner(applyFieldOwner), client.DryRunAll); err != nil {
+		if live != nil {
+			return "", err
+		}
+		Debug("%s can't be dry-run applied, diffing against the spec: %s\n", name, err)
+		merged = patch
+	}
+
+	from, err := diffableYAML(live)
+	if err != nil {
+		return "", err
+	}
+	to, err := diffableYAML(merged)
+	if err != nil {
+		return "", err
+	}
+
+	return textdiff.Unified("live/"+name, "spec/"+name, from, to, 3), nil
+}
+
+// diffableYAML renders obj as YAML without the fields that would
+// clutter a diff or leak secrets. A nil obj renders as "".
+func diffableYAML(obj client.Object) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+
+	u, err := cleanObject(obj)
+	if err != nil {
+		return "", err
+	}
+	if u["kind"] == "Secret" {
+		redact(u, "data")
+		redact(u, "stringData")
+	}
+
+	out, err := yaml.Marshal(u)
+	return string(out), err
+}
+
+// cleanObject converts obj to an unstructured map, with its TypeMeta
+// set, and removes its status and the metadata fields that the API
+// server maintains.
+func cleanObject(obj client.Object) (map[string]interface{}, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return nil, err
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u["apiVersion"], u["kind"] = gvk.GroupVersion().String(), gvk.Kind
+
+	delete(u, "status")
+	if meta, ok := u["metadata"].(map[string]interface{}); ok {
+		for _, field := range serverManagedFields {
+			delete(meta, field)
+		}
+		if annotations, ok := meta["annotations"].(map[string]interface{}); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+			if len(annotations) == 0 {
+				delete(meta, "annotations")
+			}
+		}
+	}
+
+	return u, nil
+}
+
+// redact replaces each value in u's field map with a placeholder.
+// The placeholder includes a hash of the value so a diff shows which
+// values changed without showing the values. ToUnstructured renders
+// []byte values as base64 strings so we hash those.
+func redact(u map[string]interface{}, field string) {
+	values, ok := u[field].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for key, value := range values {
+		sum := sha256.Sum256([]byte(fmt.Sprint(value)))
+		values[key] = fmt.Sprintf("<redacted sha256:%x>", sum[:6])
+	}
+}
+
+// unmanagedObjects returns the names of the Gateways, routes and
+// endpoints in namespace that aren't in desired.
+func unmanagedObjects(ctx context.Context, cl client.Client, namespace string, desired map[string]bool) ([]string, error) {
+	inNS := &client.ListOptions{Namespace: namespace}
+
+	proxies := epicv1.GWProxyList{}
+	if err := cl.List(ctx, &proxies, inNS); err != nil {
+		return nil, err
+	}
+	routes := epicv1.GWRouteList{}
+	if err := cl.List(ctx, &routes, inNS); err != nil {
+		return nil, err
+	}
+	slices := epicv1.GWEndpointSliceList{}
+	if err := cl.List(ctx, &slices, inNS); err != nil {
+		return nil, err
+	}
+
+	live := []client.Object{}
+	for i := range proxies.Items {
+		live = append(live, &proxies.Items[i])
+	}
+	for i := range routes.Items {
+		live = append(live, &routes.Items[i])
+	}
+	for i := range slices.Items {
+		live = append(live, &slices.Items[i])
+	}
+
+	extra := []string{}
+	for _, obj := range live {
+		if name := describeObject(obj); !desired[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+
+	return extra, nil
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"epic-gateway.org/epicctl/internal/printer"
+)
+
+const (
+	dryRunNone   = "none"
+	dryRunClient = "client"
+	dryRunServer = "server"
+)
+
+// recordingClient is a client.Client that remembers the objects
+// that were written so they can be output when the command
+// finishes. In "client" dry-run mode it doesn't send writes to the
+// cluster at all (reads still go to the cluster), and in "server"
+// dry-run mode it asks the API server to validate writes without
+// persisting them.
+type recordingClient struct {
+	client.Client
+
+	dryRun  string
+	objects []client.Object
+
+	// namespaces are the Namespaces that were "created" by a dry
+	// run. The API server won't accept objects in them since they
+	// don't really exist.
+	namespaces map[string]bool
+}
+
+// getCreateClient returns a client for the create commands that
+// honors the "--dry-run" and "--output" flags. The objects that it
+// writes are output by createCmd's PersistentPostRunE.
+func getCreateClient() (client.Client, error) {
+	if _, err := createOutput(); err != nil {
+		return nil, err
+	}
+
+	cl, err := getCRClient()
+	if err != nil {
+		return nil, err
+	}
+
+	createClient = &recordingClient{Client: cl, dryRun: dryRun, namespaces: map[string]bool{}}
+	return createClient, nil
+}
+
+// createOutput validates the create command's "--dry-run" and
+// "--output" flags and returns the output format.
+func createOutput() (printer.Format, error) {
+	switch dryRun {
+	case "", dryRunNone, dryRunClient, dryRunServer:
+	default:
+		return printer.Default, fmt.Errorf("unknown dry-run mode %q, must be one of none|client|server", dryRun)
+	}
+
+	format, err := output()
+	if err != nil {
+		return format, err
+	}
+	if format != printer.Default && format != printer.JSON && format != printer.YAML {
+		return format, fmt.Errorf("create commands can only output json or yaml")
+	}
+
+	return format, nil
+}
+
+// isDryRun returns true if the user asked for a dry run.
+func isDryRun() bool {
+	return dryRun == dryRunClient || dryRun == dryRunServer
+}
+
+// dryRunSuffix returns a suffix for status messages that tells the
+// user that nothing really happened.
+func dryRunSuffix() string {
+	if isDryRun() {
+		return " (dry run: " + dryRun + ")"
+	}
+	return ""
+}
+
+// createLog prints a status message. If the user asked for JSON or
+// YAML output then the message goes to stderr so it doesn't corrupt
+// the objects on stdout.
+func createLog(format string, args ...interface{}) {
+	fmt.Fprintf(createLogWriter(), format, args...)
+}
+
+// createLogWriter returns the writer to which status messages should
+// be written: stderr if the user asked for JSON or YAML output, and
+// stdout otherwise.
+func createLogWriter() io.Writer {
+	if f, _ := output(); !f.Human() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+func (c *recordingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if c.useServer(obj) {
+		if c.dryRun == dryRunServer {
+			opts = append(opts, client.DryRunAll)
+		}
+		if err := c.Client.Create(ctx, obj, opts...); err != nil {
+			return err
+		}
+	} else if err := c.checkExists(ctx, obj); err != nil {
+		return err
+	}
+	if _, isNS := obj.(*v1.Namespace); isNS && isDryRun() {
+		c.namespaces[obj.GetName()] = true
+	}
+	c.record(obj)
+	return nil
+}
+
+func (c *recordingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if c.useServer(obj) {
+		if c.dryRun == dryRunServer {
+			opts = append(opts, client.DryRunAll)
+		}
+		if err := c.Client.Update(ctx, obj, opts...); err != nil {
+			return err
+		}
+	}
+	c.record(obj)
+	return nil
+}
+
+func (c *recordingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if c.useServer(obj) {
+		if c.dryRun == dryRunServer {
+			opts = append(opts, client.DryRunAll)
+		}
+		if err := c.Client.Patch(ctx, obj, patch, opts...); err != nil {
+			return err
+		}
+	}
+	c.record(obj)
+	return nil
+}
+
+func (c *recordingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if !c.useServer(obj) {
+		return nil
+	}
+	if c.dryRun == dryRunServer {
+		opts = append(opts, client.DryRunAll)
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// checkExists returns an AlreadyExists error if obj is in the
+// cluster, so a client-side dry run reports existing objects the
+// same way that a real run would.
+func (c *recordingClient) checkExists(ctx context.Context, obj client.Object) error {
+	if c.namespaces[obj.GetNamespace()] {
+		return nil
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return err
+	}
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(gvk)
+	err = c.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if err == nil {
+		return apierrors.NewAlreadyExists(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, obj.GetName())
+	}
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// useServer returns true if writes to obj should be sent to the API
+// server.
+func (c *recordingClient) useServer(obj client.Object) bool {
+	switch c.dryRun {
+	case dryRunClient:
+		return false
+	case dryRunServer:
+		if c.namespaces[obj.GetNamespace()] {
+			Debug("%s can't be validated by the server because its namespace is part of the dry run\n", describeObject(obj))
+			return false
+		}
+	}
+	return true
+}
+
+// record saves a copy of obj for output.
+func (c *recordingClient) record(obj client.Object) {
+	cp := obj.DeepCopyObject().(client.Object)
+
+	// Objects read from the cluster don't have their TypeMeta set but
+	// the output needs it.
+	if gvk, err := apiutil.GVKForObject(cp, scheme); err == nil {
+		cp.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+
+	c.objects = append(c.objects, cp)
+}
+
+// printObjects outputs the objects that were written, in format.
+func (c *recordingClient) printObjects(format printer.Format) error {
+	if format.Human() {
+		return nil
+	}
+
+	for i, obj := range c.objects {
+		if format == printer.YAML && i > 0 {
+			fmt.Println("---")
+		}
+		if err := printer.PrintObject(os.Stdout, format, obj); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	var includeHashes bool
+
+	cmd := &cobra.Command{
+		Use:     "api-users user-namespace",
+		Aliases: []string{"api-user"},
+		Short:   "Exports api-users to CSV",
+		Long: `Exports the api-users in a user namespace to CSV on stdout.
+
+Each user's name, hash algorithm and last rotation time are
+exported. With --include-hashes the password hashes are also
+exported so the file can be imported with "epicctl import api-users".`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			return exportAPIUsers(rootCmd.Context(), cl, args[0], includeHashes)
+		},
+	}
+	cmd.Flags().BoolVar(&includeHashes, "include-hashes", false, "include the password hashes")
+	exportCmd.AddCommand(cmd)
+}
+
+// exportAPIUsers writes the api-users in the user namespace
+// accountName to stdout as CSV.
+func exportAPIUsers(ctx context.Context, cl client.Client, accountName string, includeHashes bool) error {
+	secret, err := getAPIUsersSecret(ctx, cl, accountName)
+	if err != nil {
+		return err
+	}
+	users := parseAPIUsers(secret)
+	rotated := rotationTimes(secret)
+
+	w := csv.NewWriter(os.Stdout)
+	header := []string{"username", "algorithm", "last_rotated"}
+	if includeHashes {
+		header = append(header, "hash")
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, entry := range users.Entries() {
+		record := []string{entry.User, string(entry.Algorithm()), rotated[entry.User]}
+		if includeHashes {
+			record = append(record, entry.Hash)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+
+	return w.Error()
+}
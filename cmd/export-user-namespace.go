@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"epic-gateway.org/epicctl/internal/archive"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+func init() {
+	var (
+		outFile        string
+		encrypt        bool
+		passphraseFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "user-namespace name",
+		Short:   "Exports a user namespace",
+		Aliases: []string{"ns", "user-ns"},
+		Long: `Exports a user namespace to a tar.gz archive.
+
+The archive contains the namespace's Account, Gateways (GWProxies),
+routes (GWRoutes), endpoints (GWEndpointSlices) and its api-users and
+registry Secrets. Fields that the cluster manages, like UIDs and the
+Gateways' external addresses, aren't exported since they're assigned
+again on import.
+
+The archive contains credentials. Use --encrypt to encrypt it with a
+passphrase, which is read from the terminal or --passphrase-file.
+
+  epicctl export user-namespace acme --encrypt > acme.tar.gz`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outFile == "-" && term.IsTerminal(int(os.Stdout.Fd())) {
+				return fmt.Errorf("refusing to write an archive to a terminal, redirect stdout or use --filename")
+			}
+			var passphrase []byte
+			if encrypt {
+				var err error
+				if passphrase, err = readPassphrase(passphraseFile, true); err != nil {
+					return err
+				}
+			}
+
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			data, count, err := exportUserNamespace(rootCmd.Context(), cl, args[0])
+			if err != nil {
+				return err
+			}
+			if encrypt {
+				if data, err = archive.Seal(data, passphrase); err != nil {
+					return err
+				}
+			}
+
+			if outFile == "-" {
+				_, err = os.Stdout.Write(data)
+			} else {
+				err = os.WriteFile(outFile, data, 0600)
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(os.Stderr, "exported %d objects from user namespace %s\n", count, args[0])
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&outFile, "filename", "f", "-", "the archive file to write, or - for stdout")
+	cmd.Flags().BoolVar(&encrypt, "encrypt", false, "encrypt the archive with a passphrase")
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "read the passphrase from this file instead of the terminal")
+	exportCmd.AddCommand(cmd)
+}
+
+// exportUserNamespace builds a tar.gz archive of the user namespace
+// orgName. It returns the archive and the number of objects in it.
+func exportUserNamespace(ctx context.Context, cl client.Client, orgName string) ([]byte, int, error) {
+	objs, err := exportObjects(ctx, cl, orgName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	manifest, err := yaml.Marshal(exportManifest{
+		UserNamespace: orgName,
+		ExportedAt:    time.Now().UTC().Format(time.RFC3339),
+		Version:       version,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	entries := []archive.Entry{{Name: exportManifestName, Data: manifest}}
+
+	for _, obj := range objs {
+		u, err := cleanObject(obj)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// Owner UIDs won't match on the importing cluster, and EPIC
+		// allocates each Gateway's external address so it can't be
+		// carried over.
+		if meta, ok := u["metadata"].(map[string]interface{}); ok {
+			delete(meta, "ownerReferences")
+		}
+		if _, isProxy := obj.(*epicv1.GWProxy); isProxy {
+			if spec, ok := u["spec"].(map[string]interface{}); ok {
+				delete(spec, "endpoints")
+			}
+		}
+
+		data, err := yaml.Marshal(u)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, archive.Entry{
+			Name: fmt.Sprintf("objects/%s/%s.yaml", u["kind"], obj.GetName()),
+			Data: data,
+		})
+	}
+
+	buf := bytes.Buffer{}
+	if err := archive.Write(&buf, entries, time.Now()); err != nil {
+		return nil, 0, err
+	}
+
+	return buf.Bytes(), len(objs), nil
+}
+
+// exportObjects reads the objects in the user namespace orgName that
+// make up the tenant.
+func exportObjects(ctx context.Context, cl client.Client, orgName string) ([]client.Object, error) {
+	nsName := epicv1.AccountNamespace(orgName)
+	inNS := &client.ListOptions{Namespace: nsName}
+
+	acct := epicv1.Account{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: nsName, Name: orgName}, &acct); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("user namespace %s not found", orgName)
+		}
+		return nil, err
+	}
+	objs := []client.Object{&acct}
+
+	// The secrets are optional: a namespace that was created by
+	// "apply" might not have the registry secret.
+	for _, name := range []string{contourSecretName, gitlabSecretName} {
+		secret := v1.Secret{}
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: nsName, Name: name}, &secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		objs = append(objs, &secret)
+	}
+
+	proxies := epicv1.GWProxyList{}
+	if err := cl.List(ctx, &proxies, inNS); err != nil {
+		return nil, err
+	}
+	slices := epicv1.GWEndpointSliceList{}
+	if err := cl.List(ctx, &slices, inNS); err != nil {
+		return nil, err
+	}
+	routes := epicv1.GWRouteList{}
+	if err := cl.List(ctx, &routes, inNS); err != nil {
+		return nil, err
+	}
+	for i := range proxies.Items {
+		objs = append(objs, &proxies.Items[i])
+	}
+	for i := range slices.Items {
+		objs = append(objs, &slices.Items[i])
+	}
+	for i := range routes.Items {
+		objs = append(objs, &routes.Items[i])
+	}
+
+	return objs, nil
+}
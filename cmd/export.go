@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// exportManifestName is the name of the archive entry that describes
+// the archive's contents.
+const exportManifestName = "manifest.yaml"
+
+// exportManifest describes an exported user namespace.
+type exportManifest struct {
+	// UserNamespace is the name of the exported user namespace
+	// (without the "epic-" prefix).
+	UserNamespace string `json:"userNamespace"`
+
+	ExportedAt string `json:"exportedAt"`
+	Version    string `json:"epicctlVersion"`
+}
+
+// exportCmd is a container command for the subcommands that export
+// various types of resources.
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports resources",
+	Long:  `Exports resources from EPIC so they can be backed up or imported into another cluster.`,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
+
+// readPassphrase reads an archive passphrase from file or, if file
+// is "", from the terminal. If confirm is true then the user has to
+// type it twice.
+func readPassphrase(file string, confirm bool) ([]byte, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		passphrase := strings.TrimRight(string(data), "\r\n")
+		if passphrase == "" {
+			return nil, fmt.Errorf("passphrase file %s is empty", file)
+		}
+		return []byte(passphrase), nil
+	}
+
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		return nil, fmt.Errorf("stdin is not a terminal, use --passphrase-file")
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	pass1, err := readPassword()
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if pass1 == "" {
+		return nil, fmt.Errorf("the passphrase can't be empty")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Retype Passphrase: ")
+		pass2, err := readPassword()
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if pass1 != pass2 {
+			return nil, fmt.Errorf("passphrases don't match")
+		}
+	}
+
+	return []byte(pass1), nil
+}
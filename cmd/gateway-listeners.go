@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// listenerProtocols maps the protocols that --listener accepts to
+// their Gateway API protocols.
+var listenerProtocols = map[string]v1alpha2.ProtocolType{
+	"http":  v1alpha2.HTTPProtocolType,
+	"https": v1alpha2.HTTPSProtocolType,
+	"tls":   v1alpha2.TLSProtocolType,
+}
+
+// gatewayListeners figures out an ad-hoc Gateway's listeners from
+// either its port argument (portArgs is empty or has one element) or
+// its --listener flags, but not both. HTTPS listeners use the
+// certificate in the Secret certSecret.
+func gatewayListeners(portArgs []string, flags []string, certSecret string) ([]v1alpha2.Listener, error) {
+	switch {
+	case len(portArgs) > 0 && len(flags) > 0:
+		return nil, fmt.Errorf("use either the port argument or --listener, not both")
+	case len(portArgs) > 0:
+		port, err := parsePort(portArgs[0])
+		if err != nil {
+			return nil, err
+		}
+		return []v1alpha2.Listener{httpListener(port)}, nil
+	case len(flags) == 0:
+		return nil, fmt.Errorf("a port argument or at least one --listener is required")
+	}
+
+	listeners := []v1alpha2.Listener{}
+	names := map[v1alpha2.SectionName]bool{}
+	ports := map[v1alpha2.PortNumber]bool{}
+	for _, flag := range flags {
+		listener, err := parseListener(flag, certSecret)
+		if err != nil {
+			return nil, err
+		}
+		if names[listener.Name] {
+			return nil, fmt.Errorf("listener %s: name %s is used more than once", flag, listener.Name)
+		}
+		if ports[listener.Port] {
+			return nil, fmt.Errorf("listener %s: port %d is used more than once", flag, listener.Port)
+		}
+		names[listener.Name] = true
+		ports[listener.Port] = true
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// parseListener parses a --listener value, proto:port[:name]. If
+// there's no name then it's made from the protocol and port, e.g.,
+// "https-443". HTTPS listeners use the certificate in the Secret
+// certSecret.
+func parseListener(value string, certSecret string) (v1alpha2.Listener, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v1alpha2.Listener{}, fmt.Errorf("listener %s: expected proto:port[:name]", value)
+	}
+
+	proto := strings.ToLower(parts[0])
+	protocol, ok := listenerProtocols[proto]
+	if !ok {
+		return v1alpha2.Listener{}, fmt.Errorf("listener %s: protocol must be http, https or tls", value)
+	}
+
+	port, err := parsePort(parts[1])
+	if err != nil {
+		return v1alpha2.Listener{}, fmt.Errorf("listener %s: %w", value, err)
+	}
+
+	name := fmt.Sprintf("%s-%d", proto, port)
+	if len(parts) == 3 {
+		name = parts[2]
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return v1alpha2.Listener{}, fmt.Errorf("listener %s: invalid name: %s", value, strings.Join(errs, ", "))
+		}
+	}
+
+	listener := v1alpha2.Listener{
+		Name:     v1alpha2.SectionName(name),
+		Protocol: protocol,
+		Port:     v1alpha2.PortNumber(port),
+	}
+
+	switch protocol {
+	case v1alpha2.HTTPSProtocolType:
+		mode := v1alpha2.TLSModeTerminate
+		listener.TLS = &v1alpha2.GatewayTLSConfig{
+			Mode: &mode,
+			CertificateRefs: []v1alpha2.SecretObjectReference{{
+				Name: v1alpha2.ObjectName(certSecret),
+			}},
+		}
+	case v1alpha2.TLSProtocolType:
+		mode := v1alpha2.TLSModePassthrough
+		listener.TLS = &v1alpha2.GatewayTLSConfig{
+			Mode: &mode,
+		}
+	}
+
+	return listener, nil
+}
+
+// parsePort parses a listener port number.
+func parsePort(value string) (int32, error) {
+	port, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse %s as a port value: %w", value, err)
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %d is not between 1 and 65535", port)
+	}
+	return int32(port), nil
+}
+
+// httpListener returns the listener of a Gateway that has a single
+// HTTP port.
+func httpListener(port int32) v1alpha2.Listener {
+	return v1alpha2.Listener{
+		Protocol: v1alpha2.HTTPProtocolType,
+		Port:     v1alpha2.PortNumber(port),
+		Name:     "http",
+	}
+}
+
+// hasProtocol returns true if any of listeners uses protocol.
+func hasProtocol(listeners []v1alpha2.Listener, protocol v1alpha2.ProtocolType) bool {
+	for _, listener := range listeners {
+		if listener.Protocol == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// routeListeners returns the names of the listeners to which a route
+// for one of protocols can attach, and the port of the first of them.
+// The route's backends receive traffic on that port. If there are no
+// such listeners then the slice is empty.
+func routeListeners(listeners []v1alpha2.Listener, protocols ...v1alpha2.ProtocolType) ([]v1alpha2.SectionName, int32) {
+	sections := []v1alpha2.SectionName{}
+	var port int32
+	for _, listener := range listeners {
+		for _, protocol := range protocols {
+			if listener.Protocol != protocol {
+				continue
+			}
+			if len(sections) == 0 {
+				port = int32(listener.Port)
+			}
+			sections = append(sections, listener.Name)
+		}
+	}
+	return sections, port
+}
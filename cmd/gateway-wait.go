@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"epic-gateway.org/epicctl/internal/addrpool"
+	"epic-gateway.org/epicctl/internal/health"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+// proxyWaitProgress is how often we tell the user that we're still
+// waiting for a Gateway's address.
+const proxyWaitProgress = 10 * time.Second
+
+// proxyAddress returns proxy's first endpoint that has an address,
+// or nil if it doesn't have one yet.
+func proxyAddress(proxy *epicv1.GWProxy) *epicv1.Endpoint {
+	for _, ep := range proxy.Spec.Endpoints {
+		if ep != nil && len(ep.Targets) > 0 {
+			return ep
+		}
+	}
+	return nil
+}
+
+// waitForProxyAddress waits until EPIC assigns an external address
+// to proxy, and returns the endpoint with that address. It watches
+// the GWProxy instead of polling, prints a progress message now and
+// then, and gives up when timeout expires or the user interrupts it.
+// If it gives up then the error explains what might be holding the
+// Gateway up.
+func waitForProxyAddress(ctx context.Context, cl client.Client, proxy *epicv1.GWProxy, timeout time.Duration) (*epicv1.Endpoint, error) {
+	config, err := getClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	wcl, err := client.NewWithWatch(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	defer cancelTimeout()
+
+	start := time.Now()
+	progress := time.NewTicker(proxyWaitProgress)
+	defer progress.Stop()
+
+	for {
+		// Start the watch with a fresh read so we don't miss an
+		// address that was assigned before the watch began. Watches
+		// can be closed by the server at any time so we loop.
+		latest := epicv1.GWProxy{}
+		if err := wcl.Get(ctx, client.ObjectKeyFromObject(proxy), &latest); err != nil {
+			return nil, waitError(ctx, cl, proxy, start, err)
+		}
+		if ep := proxyAddress(&latest); ep != nil {
+			return ep, nil
+		}
+
+		watcher, err := wcl.Watch(ctx, &epicv1.GWProxyList{}, &client.ListOptions{
+			Namespace:     proxy.Namespace,
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", proxy.Name),
+			Raw:           &metav1.ListOptions{ResourceVersion: latest.ResourceVersion},
+		})
+		if err != nil {
+			return nil, waitError(ctx, cl, proxy, start, err)
+		}
+
+		ep, err := watchForAddress(ctx, watcher, progress, proxy.Name, start)
+		watcher.Stop()
+		if err != nil || ep != nil {
+			if err != nil {
+				err = waitError(ctx, cl, proxy, start, err)
+			}
+			return ep, err
+		}
+	}
+}
+
+// watchForAddress processes the events from watcher until the proxy
+// gets an address, the watch closes (in which case it returns nil,
+// nil) or ctx is done.
+func watchForAddress(ctx context.Context, watcher watch.Interface, progress *time.Ticker, name string, start time.Time) (*epicv1.Endpoint, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-progress.C:
+			createLog("waiting for gateway %s to get an address (%s)\n", name, time.Since(start).Round(time.Second))
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil, nil
+			}
+			switch event.Type {
+			case watch.Deleted:
+				return nil, fmt.Errorf("gateway %s was deleted", name)
+			case watch.Error:
+				return nil, nil
+			}
+			if latest, ok := event.Object.(*epicv1.GWProxy); ok {
+				if ep := proxyAddress(latest); ep != nil {
+					return ep, nil
+				}
+			}
+		}
+	}
+}
+
+// waitError explains why we stopped waiting for proxy's address.
+func waitError(ctx context.Context, cl client.Client, proxy *epicv1.GWProxy, start time.Time, err error) error {
+	waited := time.Since(start).Round(time.Second)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("interrupted after %s; gateway %s was created and might still get an address", waited, proxy.Name)
+	case errors.Is(err, context.DeadlineExceeded):
+		msg := fmt.Sprintf("gateway %s has no address after %s", proxy.Name, waited)
+		// ctx has expired so we need a fresh one to investigate.
+		ictx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		for _, reason := range proxyStallReasons(ictx, cl, proxy) {
+			msg += "\n  " + reason
+		}
+		return errors.New(msg)
+	}
+
+	return fmt.Errorf("waiting for gateway %s: %w", proxy.Name, err)
+}
+
+// proxyStallReasons looks for reasons why proxy hasn't got an
+// address: missing or unhealthy Envoy pods, warning events, and a
+// missing or full service group.
+func proxyStallReasons(ctx context.Context, cl client.Client, proxy *epicv1.GWProxy) []string {
+	reasons := []string{}
+
+	pods := v1.PodList{}
+	if err := cl.List(ctx, &pods, client.InNamespace(proxy.Namespace), client.MatchingLabels{epicv1.OwningProxyLabel: proxy.Name}); err != nil {
+		reasons = append(reasons, fmt.Sprintf("can't list the gateway's Envoy pods: %s", err))
+	} else if len(pods.Items) == 0 {
+		reasons = append(reasons, "no Envoy pods have been created for the gateway, is the EPIC controller running? (see \"epicctl status\")")
+	} else {
+		for i := range pods.Items {
+			reasons = append(reasons, health.PodProblems(&pods.Items[i])...)
+		}
+	}
+
+	events := v1.EventList{}
+	if err := cl.List(ctx, &events, client.InNamespace(proxy.Namespace)); err == nil {
+		for _, event := range events.Items {
+			if event.Type == v1.EventTypeWarning && event.InvolvedObject.Kind == "GWProxy" && event.InvolvedObject.Name == proxy.Name {
+				reasons = append(reasons, fmt.Sprintf("%s: %s", event.Reason, event.Message))
+			}
+		}
+	}
+
+	reasons = append(reasons, serviceGroupProblems(ctx, cl, proxy)...)
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, "no problems found, check the EPIC controller's logs (see \"epicctl logs scan\")")
+	}
+
+	return reasons
+}
+
+// serviceGroupProblems checks that proxy's LBServiceGroup and the
+// ServicePrefix from which the group allocates addresses exist, and
+// that the prefix has free addresses.
+func serviceGroupProblems(ctx context.Context, cl client.Client, proxy *epicv1.GWProxy) []string {
+	groupName := proxy.Labels[epicv1.OwningLBServiceGroupLabel]
+	if groupName == "" {
+		return []string{fmt.Sprintf("gateway %s has no service group", proxy.Name)}
+	}
+
+	group := epicv1.LBServiceGroup{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: proxy.Namespace, Name: groupName}, &group); err != nil {
+		if apierrors.IsNotFound(err) {
+			return []string{fmt.Sprintf("service group %s doesn't exist in %s", groupName, proxy.Namespace)}
+		}
+		return []string{fmt.Sprintf("can't read service group %s: %s", groupName, err)}
+	}
+
+	prefixName := group.Labels[epicv1.OwningServicePrefixLabel]
+	if prefixName == "" {
+		return []string{fmt.Sprintf("service group %s has no %s label", groupName, epicv1.OwningServicePrefixLabel)}
+	}
+	prefix := epicv1.ServicePrefix{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: "epic", Name: prefixName}, &prefix); err != nil {
+		if apierrors.IsNotFound(err) {
+			return []string{fmt.Sprintf("service prefix %s, which service group %s uses, doesn't exist", prefixName, groupName)}
+		}
+		return []string{fmt.Sprintf("can't read service prefix %s: %s", prefixName, err)}
+	}
+
+	pool, err := addrpool.Parse(prefix.Spec.PublicPool.Pool)
+	if err != nil {
+		return []string{fmt.Sprintf("service prefix %s has a bad address pool: %s", prefixName, err)}
+	}
+
+	used, err := prefixAddresses(ctx, cl, prefixName, pool)
+	if err != nil {
+		return []string{fmt.Sprintf("can't count service prefix %s's free addresses: %s", prefixName, err)}
+	}
+	if uint64(used) >= pool.Size() {
+		return []string{fmt.Sprintf("service prefix %s has no free addresses (%d of %d in use)", prefixName, used, pool.Size())}
+	}
+
+	return nil
+}
+
+// prefixAddresses counts the distinct addresses in pool that are used
+// by the Gateways in the service groups (in any account) that
+// allocate addresses from the ServicePrefix prefixName.
+func prefixAddresses(ctx context.Context, cl client.Client, prefixName string, pool addrpool.Pool) (int, error) {
+	groups := epicv1.LBServiceGroupList{}
+	if err := cl.List(ctx, &groups, client.MatchingLabels{epicv1.OwningServicePrefixLabel: prefixName}); err != nil {
+		return 0, err
+	}
+	if len(groups.Items) == 0 {
+		return 0, nil
+	}
+	groupNames := []string{}
+	for _, group := range groups.Items {
+		groupNames = append(groupNames, group.Name)
+	}
+	inGroups, err := labels.NewRequirement(epicv1.OwningLBServiceGroupLabel, selection.In, groupNames)
+	if err != nil {
+		return 0, err
+	}
+
+	proxies := epicv1.GWProxyList{}
+	if err := cl.List(ctx, &proxies, client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*inGroups)}); err != nil {
+		return 0, err
+	}
+	used := map[string]bool{}
+	for _, proxy := range proxies.Items {
+		for _, ep := range proxy.Spec.Endpoints {
+			for _, target := range ep.Targets {
+				if ip := net.ParseIP(target); ip != nil && pool.Contains(ip) {
+					used[ip.String()] = true
+				}
+			}
+		}
+	}
+
+	return len(used), nil
+}
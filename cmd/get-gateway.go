@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"epic-gateway.org/epicctl/internal/printer"
+)
+
+func init() {
+	var account string
+
+	cmd := &cobra.Command{
+		Use:     "gateways",
+		Aliases: []string{"gateway", "gw", "gws"},
+		Short:   "Get gateways",
+		Long:    `Get the Gateways in a user account.`,
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output()
+			if err != nil {
+				return err
+			}
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			return showGateways(rootCmd.Context(), cl, account, format)
+		},
+	}
+	cmd.Flags().StringVar(&account, "account-name", "root", "name of the user account")
+	getCmd.AddCommand(cmd)
+}
+
+// showGateways prints a summary of the Gateways in the account.
+func showGateways(ctx context.Context, cl client.Client, account string, format printer.Format) error {
+	proxies, err := getProxies(ctx, cl, account)
+	if err != nil {
+		return err
+	}
+
+	table := proxyTable(proxies)
+	return table.Print(os.Stdout, format)
+}
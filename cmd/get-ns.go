@@ -8,15 +8,25 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"epic-gateway.org/epicctl/internal/printer"
+
 	epicv1 "epic-gateway.org/resource-model/api/v1"
 )
 
+// userNamespaceInfo is the machine-readable summary of a user
+// namespace.
+type userNamespaceInfo struct {
+	Name         string      `json:"name"`
+	Namespace    string      `json:"namespace"`
+	CreatedAt    metav1.Time `json:"createdAt"`
+	GatewayCount int         `json:"gatewayCount"`
+}
+
 func init() {
 	getCmd.AddCommand(&cobra.Command{
 		Use:     "user-namespaces",
@@ -25,6 +35,10 @@ func init() {
 		Long:    `Get user-namespaces`,
 		Args:    cobra.ExactArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output()
+			if err != nil {
+				return err
+			}
 			cs, err := getGoClientset()
 			if err != nil {
 				return err
@@ -34,14 +48,14 @@ func init() {
 				return err
 			}
 
-			return showUserNamespaces(rootCmd.Context(), cs, cl)
+			return showUserNamespaces(rootCmd.Context(), cs, cl, format)
 		},
 	})
 }
 
 // showUserNamespaces extracts and prints the names of the user
 // namespaces.
-func showUserNamespaces(ctx context.Context, cs *kubernetes.Clientset, cl client.Client) error {
+func showUserNamespaces(ctx context.Context, cs *kubernetes.Clientset, cl client.Client, format printer.Format) error {
 	nsPrefix := epicv1.ProductName + "-"
 
 	// Fetch the namespaces that are part of EPIC.
@@ -58,10 +72,14 @@ func showUserNamespaces(ctx context.Context, cs *kubernetes.Clientset, cl client
 	})
 
 	// Set up output table.
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"EPIC User NS", "Created At", "GWP Count"})
-	table.SetAutoFormatHeaders(false)
-	table.SetBorder(false)
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "EPIC User NS"},
+			{Header: "Created At"},
+			{Header: "GWP Count"},
+			{Header: "K8s Namespace", Wide: true},
+		},
+	}
 
 	for _, ns := range nsList.Items {
 
@@ -73,14 +91,19 @@ func showUserNamespaces(ctx context.Context, cs *kubernetes.Clientset, cl client
 		}
 
 		// Add a row to the output table
-		table.Append([]string{
-			strings.TrimPrefix(ns.Name, nsPrefix),
+		info := userNamespaceInfo{
+			Name:         strings.TrimPrefix(ns.Name, nsPrefix),
+			Namespace:    ns.Name,
+			CreatedAt:    ns.CreationTimestamp,
+			GatewayCount: len(proxies.Items),
+		}
+		table.Append(info.Name, info,
+			info.Name,
 			ns.CreationTimestamp.String(),
-			strconv.Itoa(len(proxies.Items)),
-		})
+			strconv.Itoa(info.GatewayCount),
+			info.Namespace,
+		)
 	}
 
-	table.Render()
-
-	return nil
+	return table.Print(os.Stdout, format)
 }
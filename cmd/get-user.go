@@ -3,52 +3,169 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"epic-gateway.org/epicctl/internal/printer"
+
 	epicv1 "epic-gateway.org/resource-model/api/v1"
 )
 
+// apiUserInfo is the machine-readable summary of an API user.
+type apiUserInfo struct {
+	Name          string `json:"name"`
+	UserNamespace string `json:"userNamespace"`
+	Algorithm     string `json:"algorithm"`
+	LastRotated   string `json:"lastRotated,omitempty"`
+}
+
 func init() {
-	getCmd.AddCommand(&cobra.Command{
+	var allNamespaces bool
+
+	cmd := &cobra.Command{
 		Use:     "api-user user-namespace ",
 		Aliases: []string{"api-user", "api-users"},
 		Short:   "Get api-users",
-		Long:    `Get api-users in a specified user namespace`,
-		Args:    cobra.ExactArgs(1),
+		Long: `Get api-users in a specified user namespace
+
+With --all-namespaces, the api-users in every user namespace are
+listed in one table, e.g., for access reviews.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if allNamespaces != (len(args) == 0) {
+				return fmt.Errorf("specify either a user-namespace or --all-namespaces")
+			}
+
+			format, err := output()
+			if err != nil {
+				return err
+			}
 			cl, err := getCRClient()
 			if err != nil {
 				return err
 			}
 
-			fmt.Printf("EPIC API Users in User Namespace %s\n", args[0])
-			return listAPIUsers(rootCmd.Context(), cl, args[0])
+			if allNamespaces {
+				return listAllAPIUsers(rootCmd.Context(), cl, format)
+			}
+			return listAPIUsers(rootCmd.Context(), cl, args[0], format)
 		},
-	})
+	}
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "list the api-users in all user namespaces")
+	getCmd.AddCommand(cmd)
+}
+
+// listAPIUsers prints the api-usernames from the api-users secret in
+// the user namespace.
+func listAPIUsers(ctx context.Context, cl client.Client, accountName string, format printer.Format) error {
+	infos, err := apiUserInfos(ctx, cl, accountName)
+	if err != nil {
+		return err
+	}
+
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "API User"},
+			{Header: "Algorithm"},
+			{Header: "Last Rotated"},
+			{Header: "User NS", Wide: true},
+		},
+	}
+	for _, info := range infos {
+		table.Append(info.Name, info, info.Name, info.Algorithm, orDash(info.LastRotated), info.UserNamespace)
+	}
+
+	if format.Human() {
+		fmt.Printf("EPIC API Users in User Namespace %s\n", accountName)
+	}
+	return table.Print(os.Stdout, format)
 }
 
-// listAPIUsers extracts and prints the api-usernames from the
-// api-users secret in the user namespace.
-func listAPIUsers(ctx context.Context, cl client.Client, accountName string) error {
+// listAllAPIUsers prints the api-users in every user namespace. User
+// namespaces whose api-users can't be read are reported on stderr
+// and skipped.
+func listAllAPIUsers(ctx context.Context, cl client.Client, format printer.Format) error {
+	nsPrefix := epicv1.ProductName + "-"
+
+	nsList := v1.NamespaceList{}
+	if err := cl.List(ctx, &nsList, client.MatchingLabels(epicv1.UserNSLabels)); err != nil {
+		return err
+	}
+	names := []string{}
+	for _, ns := range nsList.Items {
+		names = append(names, strings.TrimPrefix(ns.Name, nsPrefix))
+	}
+	sort.Strings(names)
+
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "User NS"},
+			{Header: "API User"},
+			{Header: "Algorithm"},
+			{Header: "Last Rotated"},
+		},
+	}
+	for _, name := range names {
+		infos, err := apiUserInfos(ctx, cl, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping user namespace %s: %s\n", name, err)
+			continue
+		}
+		for _, info := range infos {
+			table.Append(info.UserNamespace+"/"+info.Name, info, info.UserNamespace, info.Name, info.Algorithm, orDash(info.LastRotated))
+		}
+	}
 
-	secret := v1.Secret{}
-	err := cl.Get(ctx, client.ObjectKey{Namespace: epicv1.AccountNamespace(accountName), Name: contourSecretName}, &secret)
+	return table.Print(os.Stdout, format)
+}
+
+// apiUserInfos summarizes the api-users in the user namespace
+// accountName.
+func apiUserInfos(ctx context.Context, cl client.Client, accountName string) ([]apiUserInfo, error) {
+	secret, err := getAPIUsersSecret(ctx, cl, accountName)
 	if err != nil {
-		return fmt.Errorf("user namespace %s not found", accountName)
+		return nil, err
+	}
+	users := parseAPIUsers(secret)
+	for _, problem := range users.Problems() {
+		fmt.Fprintf(os.Stderr, "warning: the %s secret in %s has a line that isn't an api-user, %s\n", secret.Name, secret.Namespace, problem)
 	}
+	rotated := rotationTimes(secret)
 
-	httppasswd := string(secret.Data["auth"])
+	infos := []apiUserInfo{}
+	for _, entry := range users.Entries() {
+		infos = append(infos, apiUserInfo{
+			Name:          entry.User,
+			UserNamespace: accountName,
+			Algorithm:     string(entry.Algorithm()),
+			LastRotated:   rotated[entry.User],
+		})
+	}
 
-	apiusernames := strings.Fields(httppasswd)
+	return infos, nil
+}
 
-	for _, s := range apiusernames {
-		user := s[:strings.IndexByte(s, ':')]
-		fmt.Printf("  %s\n", user)
+// orDash returns s, or "-" if s is empty, for table cells.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// getAPIUsers extracts the api-usernames from the api-users secret in
+// the user namespace.
+func getAPIUsers(ctx context.Context, cl client.Client, accountName string) ([]string, error) {
+	secret, err := getAPIUsersSecret(ctx, cl, accountName)
+	if err != nil {
+		return []string{}, err
 	}
+	users := parseAPIUsers(secret)
 
-	return nil
+	return users.Users(), nil
 }
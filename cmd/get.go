@@ -2,8 +2,14 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+
+	"epic-gateway.org/epicctl/internal/printer"
 )
 
+// outputFormat is the value of the "--output" flag. The get and
+// describe commands share it.
+var outputFormat string
+
 // getCmd is a container command for the subcommands that list
 // various types of resources.
 var getCmd = &cobra.Command{
@@ -14,5 +20,11 @@ var getCmd = &cobra.Command{
 }
 
 func init() {
+	getCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", printer.FlagUsage)
 	rootCmd.AddCommand(getCmd)
 }
+
+// output parses and validates the "--output" flag.
+func output() (printer.Format, error) {
+	return printer.ParseFormat(outputFormat)
+}
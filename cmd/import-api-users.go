@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"epic-gateway.org/epicctl/internal/htpasswd"
+	"epic-gateway.org/epicctl/internal/printer"
+)
+
+// apiUserImport is an API user from an import file.
+type apiUserImport struct {
+	Name string
+	Hash string
+
+	// Rotated is when the user's password was last set, if the file
+	// says. It's zero for users whose passwords are new.
+	Rotated time.Time
+}
+
+func init() {
+	var (
+		inFile  string
+		preview bool
+		yes     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "api-users user-namespace -f users.csv",
+		Aliases: []string{"api-user"},
+		Short:   "Imports api-users from a CSV file",
+		Long: `Imports api-users into a user namespace from a CSV file.
+
+The file's first line is a header that names its columns. The
+"username" column is required, and each user needs either a
+"password" (which is checked against the password policy) or a
+"hash" (bcrypt, apr1 or sha). A user with a hash keeps the time at
+which its password was last set from the "last_rotated" column, if
+there is one, while a user with a password gets a new time. Other
+columns are ignored, so a file made by "epicctl export api-users
+--include-hashes" can be imported.
+
+  username,password
+  alice,correct-horse-battery
+  bob,Tr0ub4dor&3
+
+Users that already exist are skipped. The changes are previewed and,
+once confirmed, saved in a single update.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if inFile == "-" && !yes && !preview {
+				return fmt.Errorf("use --yes when reading users from stdin since stdin can't also be used to confirm")
+			}
+
+			var r io.Reader = os.Stdin
+			if inFile != "-" {
+				f, err := os.Open(inFile)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+			imports, err := readAPIUserCSV(r)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", inFile, err)
+			}
+
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			return importAPIUsers(rootCmd.Context(), cl, args[0], imports, preview, yes)
+		},
+	}
+	cmd.Flags().StringVarP(&inFile, "filename", "f", "", "the CSV file to read, or - for stdin")
+	cmd.Flags().BoolVar(&preview, "preview", false, "only show what would be imported")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "don't ask for confirmation")
+	cmd.MarkFlagRequired("filename")
+	importCmd.AddCommand(cmd)
+}
+
+// importAPIUsers adds imports to the user namespace accountName. It
+// prints a preview and, unless preview is true, saves the users that
+// don't already exist.
+func importAPIUsers(ctx context.Context, cl client.Client, accountName string, imports []apiUserImport, preview bool, yes bool) error {
+	secret, err := getAPIUsersSecret(ctx, cl, accountName)
+	if err != nil {
+		return err
+	}
+	users := parseAPIUsers(secret)
+
+	added, table := planAPIUserImport(users, imports)
+	if err := table.Print(os.Stdout, printer.Default); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d to add, %d to skip\n", added, len(imports)-added)
+
+	if preview || added == 0 {
+		return nil
+	}
+	if !yes {
+		ok, err := confirm(fmt.Sprintf("Add %d api-users to user-namespace %s?", added, accountName))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("import cancelled")
+		}
+	}
+
+	// The secret might have changed since the preview so the plan is
+	// made again on the fresh copy.
+	err = modifyAPIUsers(ctx, cl, accountName, func(secret *v1.Secret, users *htpasswd.File) error {
+		now := time.Now()
+		for _, user := range imports {
+			if _, exists := users.Get(user.Name); exists {
+				continue
+			}
+			if _, err := users.Set(user.Name, user.Hash); err != nil {
+				return err
+			}
+			rotated := user.Rotated
+			if rotated.IsZero() {
+				rotated = now
+			}
+			setRotated(secret, user.Name, rotated)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("api-users imported into user-namespace %s\n", accountName)
+	return nil
+}
+
+// planAPIUserImport decides what to do with each of imports given
+// the existing users. It returns the number of users that would be
+// added and a table that explains the plan.
+func planAPIUserImport(users *htpasswd.File, imports []apiUserImport) (int, printer.Table) {
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "API User"},
+			{Header: "Action"},
+		},
+	}
+
+	added := 0
+	for _, user := range imports {
+		action := "add"
+		if _, exists := users.Get(user.Name); exists {
+			action = "skip (exists)"
+		} else {
+			added++
+		}
+		table.Append(user.Name, user, user.Name, action)
+	}
+
+	return added, table
+}
+
+// readAPIUserCSV reads the users in a CSV import file. Passwords are
+// checked against the password policy and hashed. Any problem with
+// the file is an error so a bad file doesn't result in a partial
+// import.
+func readAPIUserCSV(r io.Reader) ([]apiUserImport, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	userCol, hasUser := columns["username"]
+	passwordCol, hasPassword := columns["password"]
+	hashCol, hasHash := columns["hash"]
+	rotatedCol, hasRotated := columns["last_rotated"]
+	if !hasUser || !(hasPassword || hasHash) {
+		return nil, fmt.Errorf("the header must name a username column and a password and/or hash column")
+	}
+
+	policy, err := passwordPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	imports := []apiUserImport{}
+	seen := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return imports, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		user := apiUserImport{Name: record[userCol]}
+		if err := htpasswd.ValidateUser(user.Name); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if first, dup := seen[user.Name]; dup {
+			return nil, fmt.Errorf("line %d: api-user %s is also on line %d", line, user.Name, first)
+		}
+		seen[user.Name] = line
+
+		password, hash := "", ""
+		if hasPassword {
+			password = record[passwordCol]
+		}
+		if hasHash {
+			hash = record[hashCol]
+		}
+		switch {
+		case password != "" && hash != "":
+			return nil, fmt.Errorf("line %d: api-user %s has both a password and a hash", line, user.Name)
+		case hash != "":
+			if err := htpasswd.ValidateHash(hash); err != nil {
+				return nil, fmt.Errorf("line %d: api-user %s: %w", line, user.Name, err)
+			}
+			user.Hash = hash
+			if hasRotated && record[rotatedCol] != "" {
+				if user.Rotated, err = time.Parse(time.RFC3339, record[rotatedCol]); err != nil {
+					return nil, fmt.Errorf("line %d: api-user %s: last_rotated %q is not an RFC3339 time", line, user.Name, record[rotatedCol])
+				}
+			}
+		case password != "":
+			if err := policy.Check(password, user.Name); err != nil {
+				return nil, fmt.Errorf("line %d: api-user %s: %w", line, user.Name, err)
+			}
+			if user.Hash, err = policy.Hash(password); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("line %d: api-user %s has no password or hash", line, user.Name)
+		}
+
+		imports = append(imports, user)
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"epic-gateway.org/epicctl/internal/archive"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+// importOrder is the order in which the kinds of objects in an
+// archive are created. Routes refer to Gateways and endpoints so
+// they go last.
+var importOrder = map[string]int{
+	"Account":         0,
+	"Secret":          1,
+	"GWProxy":         2,
+	"GWEndpointSlice": 3,
+	"GWRoute":         4,
+}
+
+func init() {
+	var (
+		inFile         string
+		rename         string
+		passphraseFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "user-namespace -f archive.tar.gz",
+		Short:   "Imports a user namespace",
+		Aliases: []string{"ns", "user-ns"},
+		Long: `Imports a user namespace from an archive made by "epicctl export".
+
+The user namespace is created, along with the objects in the archive.
+Use --rename to import it under a different name, e.g., to make a
+copy on the same cluster. Objects that already exist are left as-is.
+If an object can't be created then the objects that this import
+created are deleted.
+
+If the archive is encrypted then the passphrase is read from the
+terminal or --passphrase-file.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := readArchive(inFile, passphraseFile)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", inFile, err)
+			}
+
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			return importUserNamespace(rootCmd.Context(), cl, entries, rename)
+		},
+	}
+	cmd.Flags().StringVarP(&inFile, "filename", "f", "-", "the archive file to read, or - for stdin")
+	cmd.Flags().StringVar(&rename, "rename", "", "import the user namespace under this name instead of its original name")
+	cmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "read the passphrase from this file instead of the terminal")
+	importCmd.AddCommand(cmd)
+}
+
+// importUserNamespace creates the user namespace in entries, renamed
+// to rename if it's not "".
+func importUserNamespace(ctx context.Context, cl client.Client, entries []archive.Entry, rename string) error {
+	from, objs, err := parseArchive(entries)
+	if err != nil {
+		return err
+	}
+
+	to := from
+	if rename != "" {
+		to = rename
+	}
+	if errs := validation.IsDNS1123Label(to); len(errs) > 0 {
+		return fmt.Errorf("%q is not a valid user namespace name: %v", to, errs)
+	}
+	for _, obj := range objs {
+		renameObject(obj, from, to)
+	}
+
+	ns := userNamespace(to)
+	p := newProvisioner(cl)
+	for _, obj := range append([]client.Object{&ns}, objs...) {
+		if err := p.ensure(ctx, obj, adoptUserNamespaceObject); err != nil {
+			p.rollback(ctx)
+			fmt.Printf("user namespace %s not imported:\n", to)
+			p.printReport(os.Stdout)
+			return err
+		}
+	}
+
+	fmt.Printf("user namespace %s imported from %s:\n", to, from)
+	p.printReport(os.Stdout)
+
+	return nil
+}
+
+// parseArchive decodes the entries in an exported user namespace. It
+// returns the name of the user namespace and its objects in the order
+// in which they need to be created.
+func parseArchive(entries []archive.Entry) (string, []client.Object, error) {
+	manifest := exportManifest{}
+	objs := []client.Object{}
+
+	for _, entry := range entries {
+		if entry.Name == exportManifestName {
+			if err := yaml.Unmarshal(entry.Data, &manifest); err != nil {
+				return "", nil, fmt.Errorf("%s: %w", entry.Name, err)
+			}
+			continue
+		}
+
+		u := unstructured.Unstructured{}
+		if err := yaml.Unmarshal(entry.Data, &u.Object); err != nil {
+			return "", nil, fmt.Errorf("%s: %w", entry.Name, err)
+		}
+		if _, known := importOrder[u.GetKind()]; !known {
+			return "", nil, fmt.Errorf("%s: unexpected kind %q", entry.Name, u.GetKind())
+		}
+		objs = append(objs, &u)
+	}
+
+	if manifest.UserNamespace == "" {
+		return "", nil, fmt.Errorf("the archive has no %s, was it made by epicctl export?", exportManifestName)
+	}
+
+	sort.SliceStable(objs, func(i, j int) bool {
+		return importOrder[objs[i].GetObjectKind().GroupVersionKind().Kind] < importOrder[objs[j].GetObjectKind().GroupVersionKind().Kind]
+	})
+
+	return manifest.UserNamespace, objs, nil
+}
+
+// renameObject moves obj from the user namespace from to the user
+// namespace to. The Account is named after its user namespace and
+// objects are labeled with their Account's name so those change too.
+func renameObject(obj client.Object, from string, to string) {
+	obj.SetNamespace(epicv1.AccountNamespace(to))
+
+	if obj.GetObjectKind().GroupVersionKind().Kind == "Account" && obj.GetName() == from {
+		obj.SetName(to)
+	}
+
+	if labels := obj.GetLabels(); labels[epicv1.OwningAccountLabel] == from {
+		labels[epicv1.OwningAccountLabel] = to
+		obj.SetLabels(labels)
+	}
+}
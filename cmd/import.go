@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"epic-gateway.org/epicctl/internal/archive"
+)
+
+// importCmd is a container command for the subcommands that import
+// various types of resources.
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Imports resources",
+	Long:  `Imports resources into EPIC that were exported by "epicctl export".`,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
+
+// readArchive reads an archive from file, or from stdin if file is
+// "-", and decrypts it if it's sealed.
+func readArchive(file string, passphraseFile string) ([]archive.Entry, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if archive.IsSealed(data) {
+		if file == "-" && passphraseFile == "" {
+			return nil, fmt.Errorf("the archive is encrypted, use --passphrase-file when reading it from stdin")
+		}
+		passphrase, err := readPassphrase(passphraseFile, false)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = archive.Open(data, passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	return archive.Read(bytes.NewReader(data))
+}
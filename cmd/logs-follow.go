@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+
+	"epic-gateway.org/epicctl/internal/podlog"
+)
+
+// followMergeDelay is how long we hold each log line so lines from
+// the other web service replicas can be merged in timestamp order.
+const followMergeDelay = 1 * time.Second
+
+func init() {
+	var since time.Duration
+
+	followCmd := &cobra.Command{
+		Use:   "follow user-namespace",
+		Short: "Follows a user namespace's web service activity",
+		Long: `Follows the EPIC web service's activity for a user namespace.
+
+The logs of all of the web service replicas are followed and merged in
+timestamp order. Only the lines that refer to the user namespace are
+printed. epicctl keeps following the logs until it's interrupted.
+Replicas that start or restart while it's following, e.g., during an
+upgrade, are followed too.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cs, err := getGoClientset()
+			if err != nil {
+				return err
+			}
+
+			return followActivity(rootCmd.Context(), cs, args[0], since)
+		},
+	}
+	followCmd.Flags().DurationVar(&since, "since", 5*time.Minute, "also print the activity from this far in the past")
+	logsCmd.AddCommand(followCmd)
+}
+
+// followActivity prints the web service log lines that refer to the
+// user namespace as they're logged, until ctx is cancelled or the
+// user interrupts us.
+func followActivity(ctx context.Context, cs *kubernetes.Clientset, accountName string, since time.Duration) error {
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	podLogOptions := v1.PodLogOptions{
+		Follow:     true,
+		Timestamps: true,
+	}
+	if since > 0 {
+		podLogOptions.SinceSeconds = pointer.Int64Ptr(int64(since.Seconds()))
+	}
+
+	merger := podlog.Merger{Delay: followMergeDelay}
+	filter := accountLineFilter(accountName, func(line podlog.Line) {
+		merger.Add(line, time.Now())
+	})
+
+	// Web service replicas come and go, e.g., during an upgrade, and
+	// their containers restart, so we watch the pods and follow each
+	// container's log once it's running. A container's log ends when
+	// it exits so the restart count tells us whether we're already
+	// following the current one.
+	var mu sync.Mutex
+	following := map[string]bool{}
+	follow := func(obj interface{}) {
+		pod, ok := obj.(*v1.Pod)
+		if !ok {
+			return
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Running == nil {
+				continue
+			}
+			src := logSource{Namespace: pod.Namespace, Pod: pod.Name, Container: status.Name}
+			key := fmt.Sprintf("%s/%s#%d", src, pod.UID, status.RestartCount)
+
+			mu.Lock()
+			started := following[key]
+			following[key] = true
+			mu.Unlock()
+			if started {
+				continue
+			}
+
+			go func() {
+				if err := streamLog(ctx, cs, src, podLogOptions, filter); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %s: %s\n", src, err)
+				}
+			}()
+		}
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(cs, 0,
+		informers.WithNamespace("epic"),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = webServiceSelector
+		}),
+	)
+	pods := factory.Core().V1().Pods().Informer()
+	pods.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: follow,
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			follow(newObj)
+		},
+	})
+	factory.Start(ctx.Done())
+	if !toolscache.WaitForCacheSync(ctx.Done(), pods.HasSynced) {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("unable to list the web service pods")
+	}
+	if len(pods.GetStore().List()) == 0 {
+		fmt.Fprintf(os.Stderr, "warning: web service pod not found, waiting for one to start\n")
+	}
+
+	ticker := time.NewTicker(followMergeDelay / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			for _, line := range merger.Flush() {
+				fmt.Println(formatActivity(line))
+			}
+			return nil
+		case now := <-ticker.C:
+			for _, line := range merger.Ready(now) {
+				fmt.Println(formatActivity(line))
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
+
+	"epic-gateway.org/epicctl/internal/printer"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+// scanNamespaces are the namespaces whose pods are always scanned.
+var scanNamespaces = []string{"epic", "marin3r-system"}
+
+// logScanOptions configure a log scan.
+type logScanOptions struct {
+	Patterns []string
+	Since    time.Duration
+	Prefix   bool
+}
+
+func init() {
+	opts := logScanOptions{}
+
+	scanCmd := &cobra.Command{
+		Use:   "scan [user-namespace]",
+		Short: "Scans logs for errors",
+		Long: `Scans the logs of EPIC's pods for errors.
+
+The logs of the pods in the epic and marin3r-system namespaces are
+always scanned. If a user namespace is provided then the logs of the
+pods in that namespace are also scanned. Each matching line is printed
+with the namespace, pod and container that logged it, followed by a
+summary of the number of matches in each pod. Containers that haven't
+started yet have no logs, so they're skipped with a warning.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cs, err := getGoClientset()
+			if err != nil {
+				return err
+			}
+
+			namespaces := append([]string{}, scanNamespaces...)
+			if len(args) > 0 {
+				namespaces = append(namespaces, epicv1.AccountNamespace(args[0]))
+			}
+
+			return scanLogs(rootCmd.Context(), cs, namespaces, opts)
+		},
+	}
+	scanCmd.Flags().StringArrayVarP(&opts.Patterns, "pattern", "p", []string{"(?i)error"}, "regular expression to match (can be repeated)")
+	scanCmd.Flags().DurationVar(&opts.Since, "since", 0, "only scan log lines newer than this, e.g., 1h (default: all)")
+	scanCmd.Flags().BoolVar(&opts.Prefix, "prefix", true, "prefix each line with its namespace, pod and container")
+	logsCmd.AddCommand(scanCmd)
+}
+
+// scanLogs prints the log lines in the pods in namespaces that match
+// any of the patterns in opts, followed by a summary of the matches.
+func scanLogs(ctx context.Context, cs *kubernetes.Clientset, namespaces []string, opts logScanOptions) error {
+	patterns := []*regexp.Regexp{}
+	for _, p := range opts.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("can't parse pattern %q: %w", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	pods := []v1.Pod{}
+	for _, ns := range namespaces {
+		list, err := cs.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		pods = append(pods, list.Items...)
+	}
+
+	logOptions := v1.PodLogOptions{}
+	if opts.Since > 0 {
+		logOptions.SinceSeconds = pointer.Int64Ptr(int64(opts.Since.Seconds()))
+	}
+
+	var mu sync.Mutex
+	matches := map[string]int{}
+	for _, pod := range pods {
+		matches[pod.Namespace+"/"+pod.Name] = 0
+	}
+
+	sources, notStarted := podLogSources(pods)
+	for _, src := range notStarted {
+		fmt.Fprintf(os.Stderr, "warning: %s hasn't started, skipping its log\n", src)
+	}
+
+	err := streamLogs(ctx, cs, sources, logOptions, func(src logSource, line string) {
+		for _, re := range patterns {
+			if re.MatchString(line) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				matches[src.Namespace+"/"+src.Pod]++
+				if opts.Prefix {
+					fmt.Printf("[%s] %s\n", src, line)
+				} else {
+					fmt.Println(line)
+				}
+				return
+			}
+		}
+	})
+
+	// Print the summary even if some of the logs couldn't be read.
+	names := []string{}
+	for pod := range matches {
+		names = append(names, pod)
+	}
+	sort.Strings(names)
+
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "Pod"},
+			{Header: "Matches"},
+		},
+	}
+	for _, pod := range names {
+		table.Append(pod, nil, pod, strconv.Itoa(matches[pod]))
+	}
+	fmt.Println()
+	if perr := table.Print(os.Stdout, printer.Default); perr != nil {
+		return perr
+	}
+
+	return err
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+// logsCmd is a container command for the subcommands that read the
+// logs of EPIC's pods.
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Reads logs",
+	Long:  `Reads the logs of the pods that make up EPIC.`,
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+}
+
+// logSource identifies one container's log.
+type logSource struct {
+	Namespace string
+	Pod       string
+	Container string
+}
+
+func (s logSource) String() string {
+	return s.Namespace + "/" + s.Pod + "/" + s.Container
+}
+
+// podLogSources returns a logSource for each container (including
+// init containers) in pods that has started, i.e., that has a log to
+// read. The containers that haven't started yet are returned
+// separately.
+func podLogSources(pods []v1.Pod) (started []logSource, notStarted []logSource) {
+	started = []logSource{}
+	notStarted = []logSource{}
+	for _, pod := range pods {
+		statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			src := logSource{Namespace: pod.Namespace, Pod: pod.Name, Container: cs.Name}
+			if cs.State.Running != nil || cs.State.Terminated != nil || cs.LastTerminationState.Terminated != nil {
+				started = append(started, src)
+			} else {
+				notStarted = append(notStarted, src)
+			}
+		}
+	}
+	return started, notStarted
+}
+
+// streamLogs reads the logs of each of sources concurrently and
+// calls fn with each line. fn is called from multiple goroutines so
+// it needs to do its own locking. streamLogs returns when all of the
+// streams have ended. A failure to read one log doesn't stop the
+// others; the errors are returned together.
+func streamLogs(ctx context.Context, cs *kubernetes.Clientset, sources []logSource, opts v1.PodLogOptions, fn func(src logSource, line string)) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = []error{}
+	)
+
+	for _, src := range sources {
+		wg.Add(1)
+		go func(src logSource) {
+			defer wg.Done()
+			if err := streamLog(ctx, cs, src, opts, fn); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", src, err))
+				mu.Unlock()
+			}
+		}(src)
+	}
+	wg.Wait()
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// streamLog reads the log of src and calls fn with each line.
+func streamLog(ctx context.Context, cs *kubernetes.Clientset, src logSource, opts v1.PodLogOptions, fn func(src logSource, line string)) error {
+	opts.Container = src.Container
+	stream, err := cs.CoreV1().Pods(src.Namespace).GetLogs(src.Pod, &opts).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		fn(src, scanner.Text())
+	}
+
+	// If we were cancelled then the error is expected.
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+
+	"epic-gateway.org/epicctl/internal/pwpolicy"
+)
+
+const (
+	// passwordPolicyKey is the config file key for the password
+	// policy.
+	passwordPolicyKey = "password-policy"
+
+	// generatedPasswordLength is the length of the passwords that
+	// --generate makes, unless the policy requires longer ones.
+	generatedPasswordLength = 24
+
+	// passwordAlphabet is the set of characters from which generated
+	// passwords are drawn. It leaves out characters that are easy to
+	// confuse, and ":" since it's the htpasswd separator.
+	passwordAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789!#%+-=?@^_"
+)
+
+// passwordOptions are the ways in which a command can get an API
+// user's password. If none of them are set then the password is
+// read interactively.
+type passwordOptions struct {
+	Stdin    bool
+	File     string
+	Hash     string
+	Generate bool
+}
+
+// addFlags adds the password flags to cmd.
+func (o *passwordOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.Stdin, "password-stdin", false, "read the password from the first line of stdin")
+	cmd.Flags().StringVar(&o.File, "password-file", "", "read the password from the first line of this file")
+	cmd.Flags().StringVar(&o.Hash, "password-hash", "", "use this pre-computed bcrypt hash instead of a password")
+	cmd.Flags().BoolVar(&o.Generate, "generate", false, "generate a random password and print it once")
+}
+
+// validate checks that at most one password source was requested.
+func (o *passwordOptions) validate() error {
+	count := 0
+	for _, set := range []bool{o.Stdin, o.File != "", o.Hash != "", o.Generate} {
+		if set {
+			count++
+		}
+	}
+	if count > 1 {
+		return fmt.Errorf("only one of --password-stdin, --password-file, --password-hash and --generate can be used")
+	}
+	return nil
+}
+
+// passwordHash gets user's password from the source that the user
+// asked for, checks it against the password policy, and returns its
+// bcrypt hash. If the password was generated then it's also returned
+// so the caller can show it to the user (once the hash has been
+// saved). Pre-computed hashes can't be checked so they're used as-is.
+func (o *passwordOptions) passwordHash(user string) (hash string, generated string, err error) {
+	if err := o.validate(); err != nil {
+		return "", "", err
+	}
+	if o.Hash != "" {
+		if _, err := bcrypt.Cost([]byte(o.Hash)); err != nil {
+			return "", "", fmt.Errorf("--password-hash is not a bcrypt hash: %w", err)
+		}
+		return o.Hash, "", nil
+	}
+
+	policy, err := passwordPolicy()
+	if err != nil {
+		return "", "", err
+	}
+
+	var password string
+	switch {
+	case o.Generate:
+		if password, err = generatePassword(policy, user); err != nil {
+			return "", "", err
+		}
+		generated = password
+	case o.Stdin:
+		if password, err = readPasswordLine(os.Stdin); err != nil {
+			return "", "", fmt.Errorf("reading password from stdin: %w", err)
+		}
+	case o.File != "":
+		f, err := os.Open(o.File)
+		if err != nil {
+			return "", "", err
+		}
+		defer f.Close()
+		if password, err = readPasswordLine(f); err != nil {
+			return "", "", fmt.Errorf("reading password from %s: %w", o.File, err)
+		}
+	default:
+		if password, err = promptPassword(); err != nil {
+			return "", "", err
+		}
+	}
+
+	if err := policy.Check(password, user); err != nil {
+		return "", "", err
+	}
+
+	hash, err = policy.Hash(password)
+	if err != nil {
+		return "", "", err
+	}
+
+	return hash, generated, nil
+}
+
+// passwordPolicy reads the password policy from the config file, for
+// example:
+//
+//	password-policy:
+//	  min-length: 12
+//	  required-classes: [lower, upper, digit, symbol]
+//	  denylist: [acme2022]
+//	  denylist-file: /etc/epicctl/denylist.txt
+//	  allow-username: false
+//	  bcrypt-cost: 12
+//
+// Settings that aren't in the config file keep their defaults.
+func passwordPolicy() (*pwpolicy.Policy, error) {
+	policy := pwpolicy.Default()
+	if err := viper.UnmarshalKey(passwordPolicyKey, &policy); err != nil {
+		return nil, fmt.Errorf("reading %s from the config file: %w", passwordPolicyKey, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("%s in the config file: %w", passwordPolicyKey, err)
+	}
+	if err := policy.LoadDenylistFile(); err != nil {
+		return nil, fmt.Errorf("%s in the config file: %w", passwordPolicyKey, err)
+	}
+
+	return &policy, nil
+}
+
+// promptPassword asks the user to type a new password twice.
+func promptPassword() (string, error) {
+	createLog("New Password:  ")
+	pass1, err := readPassword()
+	if err != nil {
+		return "", err
+	}
+
+	createLog("\nRetype New Password:  ")
+	pass2, err := readPassword()
+	if err != nil {
+		return "", err
+	}
+	createLog("\n")
+
+	if pass1 != pass2 {
+		return "", fmt.Errorf("passwords don't match")
+	}
+
+	return pass1, nil
+}
+
+// readPasswordLine reads the first line of r, without its line
+// ending.
+func readPasswordLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("no password found")
+	}
+	return line, nil
+}
+
+// generatePassword returns a random password for user that
+// satisfies policy. A random password can be unlucky and miss a
+// required character class so we try a few times.
+func generatePassword(policy *pwpolicy.Policy, user string) (string, error) {
+	length := generatedPasswordLength
+	if policy.MinLength > length {
+		length = policy.MinLength
+	}
+	max := big.NewInt(int64(len(passwordAlphabet)))
+
+	for attempt := 0; attempt < 100; attempt++ {
+		password := make([]byte, length)
+		for i := range password {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			password[i] = passwordAlphabet[n.Int64()]
+		}
+		if policy.Check(string(password), user) == nil {
+			return string(password), nil
+		}
+	}
+
+	return "", fmt.Errorf("can't generate a password that satisfies the password policy")
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// provisioner creates a group of objects as a unit. It remembers the
+// objects that it created so it can delete them if a later step
+// fails, and it records what it did so it can report to the user.
+type provisioner struct {
+	cl      client.Client
+	created []client.Object
+	report  []string
+}
+
+// newProvisioner creates a provisioner that uses cl to talk to the
+// cluster.
+func newProvisioner(cl client.Client) *provisioner {
+	return &provisioner{cl: cl}
+}
+
+// ensure creates obj. If obj already exists then it's adopted, i.e.,
+// it's left as-is and it won't be deleted by rollback. If adopt is
+// non-nil then it's called with the existing object so the caller
+// can bring it up to date, and if it returns true then the existing
+// object is updated.
+func (p *provisioner) ensure(ctx context.Context, obj client.Object, adopt func(existing client.Object) bool) error {
+	name := describeObject(obj)
+
+	err := p.cl.Create(ctx, obj)
+	if err == nil {
+		p.created = append(p.created, obj)
+		p.report = append(p.report, name+" created")
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("creating %s: %w", name, err)
+	}
+
+	if adopt != nil {
+		if err := p.cl.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		if adopt(obj) {
+			if err := p.cl.Update(ctx, obj); err != nil {
+				return fmt.Errorf("updating %s: %w", name, err)
+			}
+			p.report = append(p.report, name+" already exists, updated")
+			return nil
+		}
+	}
+
+	p.report = append(p.report, name+" already exists, adopted")
+	return nil
+}
+
+// rollbackTimeout is how long rollback waits for the objects that it
+// deleted to go away. Namespaces can take a while since everything in
+// them has to be deleted first.
+const rollbackTimeout = 1 * time.Minute
+
+// rollback deletes the objects that were created by this
+// provisioner, newest first, and waits for them to go away.
+func (p *provisioner) rollback(ctx context.Context) {
+	deleted := []client.Object{}
+	for i := len(p.created) - 1; i >= 0; i-- {
+		obj := p.created[i]
+		if err := p.cl.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			p.report = append(p.report, fmt.Sprintf("%s could not be rolled back: %s", describeObject(obj), err))
+			continue
+		}
+		deleted = append(deleted, obj)
+	}
+	p.created = nil
+
+	stuck := map[client.Object]bool{}
+	for _, obj := range waitForDeletion(ctx, p.cl, deleted, rollbackTimeout) {
+		stuck[obj] = true
+	}
+	for _, obj := range deleted {
+		if stuck[obj] {
+			p.report = append(p.report, describeObject(obj)+" rolled back, deletion still in progress")
+			continue
+		}
+		p.report = append(p.report, describeObject(obj)+" rolled back")
+	}
+}
+
+// printReport writes what the provisioner did to w.
+func (p *provisioner) printReport(w io.Writer) {
+	for _, line := range p.report {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// describeObject returns a human-readable identifier for obj, e.g.,
+// "Secret epic-acme/gitlab".
+func describeObject(obj client.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		if gvk, err := apiutil.GVKForObject(obj, scheme); err == nil {
+			kind = gvk.Kind
+		}
+	}
+
+	if obj.GetNamespace() == "" {
+		return kind + " " + obj.GetName()
+	}
+	return kind + " " + obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"epic-gateway.org/epicctl/internal/health"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
+)
+
+// statusWatcher keeps a live view of the cluster's health, built
+// from informer events, and prints each change. Informers call their
+// handlers from their own goroutines so everything is protected by
+// a mutex.
+type statusWatcher struct {
+	sync.Mutex
+
+	// problems maps an object's key (e.g., "pod epic/foo") to that
+	// object's current problems.
+	problems map[string][]string
+
+	// synced is false until the informers' initial lists have been
+	// processed. We don't print events until then.
+	synced bool
+
+	// degraded is true if any object has problems.
+	degraded bool
+
+	// rechecks maps a pod's key to the timer that will recheck it
+	// when its pendingGrace runs out.
+	rechecks map[string]*time.Timer
+}
+
+// pendingGrace is how long a pod can be Pending before we count it
+// as a problem, unless one of its containers has already failed.
+const pendingGrace = 2 * time.Minute
+
+// watchStatus watches the cluster and prints changes in its health
+// until ctx is cancelled or the user interrupts us.
+func watchStatus(ctx context.Context) error {
+	config, err := getClientConfig()
+	if err != nil {
+		return err
+	}
+
+	// Pods are watched in the EPIC system namespaces, and in the user
+	// namespaces only if they belong to a Gateway, so we don't cache
+	// every pod in the cluster.
+	envoyPods, err := labels.Parse(epicv1.OwningProxyLabel)
+	if err != nil {
+		return err
+	}
+	clusterInformers, err := cache.New(config, cache.Options{
+		Scheme:            scheme,
+		SelectorsByObject: cache.SelectorsByObject{&v1.Pod{}: {Label: envoyPods}},
+	})
+	if err != nil {
+		return err
+	}
+	systemInformers, err := cache.MultiNamespacedCacheBuilder(scanNamespaces)(config, cache.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	accounts := &unstructured.Unstructured{}
+	accounts.SetGroupVersionKind(epicv1.GroupVersion.WithKind("Account"))
+
+	w := &statusWatcher{problems: map[string][]string{}, rechecks: map[string]*time.Timer{}}
+	defer w.stopRechecks()
+	handlers := []struct {
+		informers cache.Cache
+		obj       client.Object
+		handler   toolscache.ResourceEventHandler
+	}{
+		{systemInformers, &v1.Pod{}, w.podHandler(ctx, systemInformers)},
+		{clusterInformers, &v1.Pod{}, w.podHandler(ctx, clusterInformers)},
+		{clusterInformers, &v1.Node{}, w.nodeHandler()},
+		{clusterInformers, &epicv1.GWProxy{}, w.proxyHandler()},
+		{clusterInformers, accounts, w.accountHandler()},
+	}
+	for _, h := range handlers {
+		informer, err := h.informers.GetInformer(ctx, h.obj)
+		if err != nil {
+			return err
+		}
+		informer.AddEventHandler(h.handler)
+	}
+
+	failed := make(chan error, 2)
+	for _, informers := range []cache.Cache{systemInformers, clusterInformers} {
+		go func(informers cache.Cache) {
+			if err := informers.Start(ctx); err != nil {
+				failed <- err
+			}
+		}(informers)
+	}
+	for _, informers := range []cache.Cache{systemInformers, clusterInformers} {
+		if !informers.WaitForCacheSync(ctx) {
+			select {
+			case err := <-failed:
+				return fmt.Errorf("watch failed: %w", err)
+			default:
+				return fmt.Errorf("unable to load the cluster's initial state")
+			}
+		}
+	}
+	w.start()
+
+	select {
+	case err := <-failed:
+		return fmt.Errorf("watch failed: %w", err)
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// start prints the initial health view and enables event output.
+func (w *statusWatcher) start() {
+	w.Lock()
+	defer w.Unlock()
+
+	w.synced = true
+	w.degraded = w.problemCount() > 0
+
+	if !w.degraded {
+		w.print("EPIC", "healthy")
+		return
+	}
+
+	w.print("EPIC", fmt.Sprintf("degraded (%d problems)", w.problemCount()))
+	keys := []string{}
+	for key := range w.problems {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, problem := range w.problems[key] {
+			fmt.Printf("  %s\n", problem)
+		}
+	}
+}
+
+// event prints a change to the object identified by key.
+func (w *statusWatcher) event(key string, change string) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.synced {
+		w.print(key, change)
+	}
+}
+
+// set records the current problems of the object identified by key
+// and prints a message if the overall health changes as a result.
+func (w *statusWatcher) set(key string, problems []string) {
+	w.Lock()
+	defer w.Unlock()
+
+	if len(problems) == 0 {
+		delete(w.problems, key)
+	} else {
+		w.problems[key] = problems
+	}
+
+	if !w.synced {
+		return
+	}
+
+	switch count := w.problemCount(); {
+	case !w.degraded && count > 0:
+		w.degraded = true
+		w.print("EPIC", fmt.Sprintf("healthy -> degraded (%d problems)", count))
+	case w.degraded && count == 0:
+		w.degraded = false
+		w.print("EPIC", "degraded -> healthy")
+	}
+}
+
+// stopRecheck cancels the recheck of the pod identified by key, if
+// there is one.
+func (w *statusWatcher) stopRecheck(key string) {
+	w.Lock()
+	defer w.Unlock()
+
+	if timer, has := w.rechecks[key]; has {
+		timer.Stop()
+		delete(w.rechecks, key)
+	}
+}
+
+// stopRechecks cancels all of the pending pod rechecks.
+func (w *statusWatcher) stopRechecks() {
+	w.Lock()
+	defer w.Unlock()
+
+	for key, timer := range w.rechecks {
+		timer.Stop()
+		delete(w.rechecks, key)
+	}
+}
+
+// problemCount returns the number of problems in the cluster. The
+// caller must hold the lock.
+func (w *statusWatcher) problemCount() int {
+	count := 0
+	for _, problems := range w.problems {
+		count += len(problems)
+	}
+	return count
+}
+
+// print outputs a timestamped message. The caller must hold the
+// lock.
+func (w *statusWatcher) print(key string, message string) {
+	fmt.Printf("%s %s %s\n", time.Now().Format(time.RFC3339), key, message)
+}
+
+// podHandler handles the events from one pod informer. A pod that's
+// still starting isn't counted as a problem until it has been Pending
+// for pendingGrace, at which point we look it up in informers again to
+// see whether it has made progress.
+func (w *statusWatcher) podHandler(ctx context.Context, informers client.Reader) toolscache.ResourceEventHandler {
+	podKey := func(pod *v1.Pod) string {
+		return "pod " + pod.Namespace + "/" + pod.Name
+	}
+	podProblems := func(pod *v1.Pod) []string {
+		if health.PodStarting(pod) && time.Since(pod.CreationTimestamp.Time) < pendingGrace {
+			return nil
+		}
+		return health.PodProblems(pod)
+	}
+	recheck := func(pod *v1.Pod) {
+		if !health.PodStarting(pod) {
+			return
+		}
+		key := client.ObjectKeyFromObject(pod)
+		recheckKey := podKey(pod)
+
+		// The lock is held until the timer is saved so the callback
+		// can't run before then.
+		w.Lock()
+		defer w.Unlock()
+		if old, has := w.rechecks[recheckKey]; has {
+			old.Stop()
+		}
+		var timer *time.Timer
+		timer = time.AfterFunc(pendingGrace-time.Since(pod.CreationTimestamp.Time), func() {
+			w.Lock()
+			if w.rechecks[recheckKey] == timer {
+				delete(w.rechecks, recheckKey)
+			}
+			w.Unlock()
+
+			current := v1.Pod{}
+			if err := informers.Get(ctx, key, &current); err != nil {
+				// The pod is gone, or we're shutting down.
+				return
+			}
+			w.set(podKey(&current), podProblems(&current))
+		})
+		w.rechecks[recheckKey] = timer
+	}
+
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*v1.Pod); ok {
+				w.event(podKey(pod), "created")
+				w.set(podKey(pod), podProblems(pod))
+				recheck(pod)
+			}
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			old, ok := oldObj.(*v1.Pod)
+			if !ok {
+				return
+			}
+			if pod, ok := newObj.(*v1.Pod); ok {
+				for _, transition := range health.PodTransitions(old, pod) {
+					w.event(podKey(pod), transition)
+				}
+				w.set(podKey(pod), podProblems(pod))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if pod, ok := deletedObject(obj).(*v1.Pod); ok {
+				w.stopRecheck(podKey(pod))
+				w.event(podKey(pod), "deleted")
+				w.set(podKey(pod), nil)
+			}
+		},
+	}
+}
+
+func (w *statusWatcher) nodeHandler() toolscache.ResourceEventHandler {
+	nodeProblems := func(node *v1.Node) []string {
+		if health.NodeReady(node) {
+			return nil
+		}
+		return []string{fmt.Sprintf("node %s is not ready", node.Name)}
+	}
+
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if node, ok := obj.(*v1.Node); ok {
+				w.event("node "+node.Name, "added")
+				w.set("node "+node.Name, nodeProblems(node))
+			}
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			old, ok := oldObj.(*v1.Node)
+			if !ok {
+				return
+			}
+			if node, ok := newObj.(*v1.Node); ok {
+				for _, transition := range health.NodeTransitions(old, node) {
+					w.event("node "+node.Name, transition)
+				}
+				w.set("node "+node.Name, nodeProblems(node))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if node, ok := deletedObject(obj).(*v1.Node); ok {
+				w.event("node "+node.Name, "removed")
+				w.set("node "+node.Name, nil)
+			}
+		},
+	}
+}
+
+func (w *statusWatcher) proxyHandler() toolscache.ResourceEventHandler {
+	proxyKey := func(proxy *epicv1.GWProxy) string {
+		return "gateway " + proxy.Namespace + "/" + proxy.Name
+	}
+	proxyProblems := func(proxy *epicv1.GWProxy) []string {
+		if len(proxy.Spec.Endpoints) > 0 {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s has no external address", proxyKey(proxy))}
+	}
+
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if proxy, ok := obj.(*epicv1.GWProxy); ok {
+				w.event(proxyKey(proxy), "created")
+				w.set(proxyKey(proxy), proxyProblems(proxy))
+			}
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			old, ok := oldObj.(*epicv1.GWProxy)
+			if !ok {
+				return
+			}
+			if proxy, ok := newObj.(*epicv1.GWProxy); ok {
+				switch {
+				case len(old.Spec.Endpoints) > 0 && len(proxy.Spec.Endpoints) == 0:
+					w.event(proxyKey(proxy), "lost its endpoint")
+				case len(old.Spec.Endpoints) == 0 && len(proxy.Spec.Endpoints) > 0:
+					w.event(proxyKey(proxy), "got endpoint "+strings.Join(proxy.Spec.Endpoints[0].Targets, ","))
+				}
+				w.set(proxyKey(proxy), proxyProblems(proxy))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if proxy, ok := deletedObject(obj).(*epicv1.GWProxy); ok {
+				w.event(proxyKey(proxy), "deleted")
+				w.set(proxyKey(proxy), nil)
+			}
+		},
+	}
+}
+
+// accountHandler handles the events from the Account informer.
+// Accounts are watched as unstructured objects, like conditionProblems
+// reads them, so we check whatever conditions the resource model
+// reports.
+func (w *statusWatcher) accountHandler() toolscache.ResourceEventHandler {
+	accountKey := func(acct *unstructured.Unstructured) string {
+		return "account " + acct.GetName()
+	}
+	accountProblems := func(acct *unstructured.Unstructured) []string {
+		conditions, err := health.ObjectConditions(acct)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return health.ConditionProblems("Account", acct.GetName(), conditions)
+	}
+
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if acct, ok := obj.(*unstructured.Unstructured); ok {
+				w.event(accountKey(acct), "created")
+				w.set(accountKey(acct), accountProblems(acct))
+			}
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			if acct, ok := newObj.(*unstructured.Unstructured); ok {
+				w.set(accountKey(acct), accountProblems(acct))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if acct, ok := deletedObject(obj).(*unstructured.Unstructured); ok {
+				w.event(accountKey(acct), "deleted")
+				w.set(accountKey(acct), nil)
+			}
+		},
+	}
+}
+
+// deletedObject unwraps the object from a delete notification. If
+// the informer missed the delete then it wraps the last known state
+// of the object in a DeletedFinalStateUnknown.
+func deletedObject(obj interface{}) interface{} {
+	if unknown, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		return unknown.Obj
+	}
+	return obj
+}
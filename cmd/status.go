@@ -3,19 +3,56 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/spf13/cobra"
+
+	"epic-gateway.org/epicctl/internal/health"
+	"epic-gateway.org/epicctl/internal/printer"
+
+	epicv1 "epic-gateway.org/resource-model/api/v1"
 )
 
+// componentHealth is the health of one part of the EPIC system,
+// e.g., the system pods or a user namespace.
+type componentHealth struct {
+	Name     string
+	Pods     int
+	Restarts int32
+	Problems []string
+}
+
 func init() {
-	rootCmd.AddCommand(&cobra.Command{
+	var watch bool
+
+	statusCmd := &cobra.Command{
 		Use:   "status",
 		Short: "EPIC operational status",
-		Long:  `Queries the EPIC cluster to determine its operational status.`,
+		Long: `Queries the EPIC cluster to determine its operational status.
+
+Checks the EPIC system workloads and pods, and the Envoy pods and the
+Account and Gateway status conditions in each user namespace, and
+prints a summary of each component's health. The exit code is
+non-zero if any problems were found.
+
+With --watch, epicctl keeps watching the cluster and prints each
+change in health (e.g., a pod restarting or a gateway losing its
+endpoint) until it's interrupted. Pods that are still starting aren't
+counted as problems until they've been Pending for two minutes.`,
+		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if watch {
+				return watchStatus(rootCmd.Context())
+			}
+
 			client, err := getCRClient()
 			if err != nil {
 				panic(err.Error())
@@ -28,83 +65,254 @@ func init() {
 
 			return nil
 		},
-	})
-
+	}
+	statusCmd.Flags().BoolVarP(&watch, "watch", "w", false, "watch the cluster and print changes in health until interrupted")
+	rootCmd.AddCommand(statusCmd)
 }
 
 // status determines the overall system status by calling more
 // specific status functions, e.g., systemPodStatus.
 func status(ctx context.Context, cl client.Client) error {
-	var err error
+	report := []*componentHealth{}
 
-	err = epicPodStatus(ctx, cl)
+	system, err := epicPodStatus(ctx, cl)
 	if err != nil {
 		return err
 	}
+	report = append(report, system)
 
-	err = userPodStatus(ctx, cl)
+	users, err := userPodStatus(ctx, cl)
 	if err != nil {
 		return err
 	}
+	report = append(report, users...)
+
+	problems := printStatus(report)
+	if problems > 0 {
+		return fmt.Errorf("%d problems found", problems)
+	}
 
 	fmt.Println("No problems found")
 
 	return nil
 }
 
+// printStatus prints a summary table of report followed by the
+// details of each problem. It returns the number of problems found.
+func printStatus(report []*componentHealth) int {
+	problems := 0
+
+	table := printer.Table{
+		Columns: []printer.Column{
+			{Header: "Component"},
+			{Header: "Pods"},
+			{Header: "Restarts"},
+			{Header: "Status"},
+		},
+	}
+	for _, c := range report {
+		state := "OK"
+		if len(c.Problems) > 0 {
+			state = "DEGRADED"
+			problems += len(c.Problems)
+		}
+		table.Append(c.Name, c, c.Name, strconv.Itoa(c.Pods), strconv.Itoa(int(c.Restarts)), state)
+	}
+	table.Print(os.Stdout, printer.Default)
+
+	if problems > 0 {
+		fmt.Println("\nProblems")
+		for _, c := range report {
+			for _, problem := range c.Problems {
+				fmt.Printf("  %s: %s\n", c.Name, problem)
+			}
+		}
+	}
+
+	return problems
+}
+
 // epicPodStatus checks the pods that run in the epic namespace.
-func epicPodStatus(ctx context.Context, cl client.Client) error {
-	nodes := v1.NodeList{}
-	err := cl.List(ctx, &nodes)
+func epicPodStatus(ctx context.Context, cl client.Client) (*componentHealth, error) {
+	system := &componentHealth{Name: "epic"}
 
 	pods := v1.PodList{}
-	err = cl.List(ctx, &pods, &client.ListOptions{Namespace: "epic"})
+	if err := cl.List(ctx, &pods, &client.ListOptions{Namespace: "epic"}); err != nil {
+		return system, err
+	}
+
+	// Does each workload have the pods that it wants?
+	owned, err := epicWorkloadStatus(ctx, cl, system, pods.Items)
 	if err != nil {
-		return err
+		return system, err
 	}
 
-	// Do we have the correct number of EPIC pods?
-	if err = epicPodCount(&nodes, &pods); err != nil {
-		return err
+	// Are all of the pods running? The workload check explains the
+	// problems with the pods that it owns so we only need to look at
+	// the others here.
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		system.Pods++
+		system.Restarts += health.PodRestarts(pod)
+		if !owned[pod.Name] {
+			system.Problems = append(system.Problems, health.PodProblems(pod)...)
+		}
 	}
 
-	// Are all of the pods running?
-	err = podStatus(ctx, cl, &nodes, &pods)
+	return system, nil
+}
+
+// epicWorkloadStatus compares the pods in the epic namespace with
+// what the DaemonSets and Deployments in that namespace expect, and
+// records any shortfalls in system. It returns the names of the pods
+// that belong to a workload.
+func epicWorkloadStatus(ctx context.Context, cl client.Client, system *componentHealth, pods []v1.Pod) (map[string]bool, error) {
+	owned := map[string]bool{}
+
+	daemonSets := appsv1.DaemonSetList{}
+	if err := cl.List(ctx, &daemonSets, &client.ListOptions{Namespace: "epic"}); err != nil {
+		return owned, err
+	}
+	for i := range daemonSets.Items {
+		ds := &daemonSets.Items[i]
+		problems, err := health.DaemonSetProblems(ds, pods)
+		if err != nil {
+			return owned, err
+		}
+		system.Problems = append(system.Problems, problems...)
+		if err := markOwned(owned, ds.Spec.Selector, pods); err != nil {
+			return owned, err
+		}
+	}
+
+	deployments := appsv1.DeploymentList{}
+	if err := cl.List(ctx, &deployments, &client.ListOptions{Namespace: "epic"}); err != nil {
+		return owned, err
+	}
+	for i := range deployments.Items {
+		dep := &deployments.Items[i]
+		problems, err := health.DeploymentProblems(dep, pods)
+		if err != nil {
+			return owned, err
+		}
+		system.Problems = append(system.Problems, problems...)
+		if err := markOwned(owned, dep.Spec.Selector, pods); err != nil {
+			return owned, err
+		}
+	}
+
+	return owned, nil
+}
+
+// markOwned adds the names of the pods that match selector to owned.
+func markOwned(owned map[string]bool, selector *metav1.LabelSelector, pods []v1.Pod) error {
+	selected, err := health.SelectPods(selector, pods)
 	if err != nil {
 		return err
 	}
-	fmt.Println("All EPIC system pods are operational")
-
+	for _, pod := range selected {
+		owned[pod.Name] = true
+	}
 	return nil
 }
 
-// epicPodCount validates that the correct number of pods are running
-// in the epic namespace.
-func epicPodCount(nodes *v1.NodeList, pods *v1.PodList) error {
-	// The count should be the sum of the agent daemonset + the web
-	// service + the controller-manager
-	desiredCount := len(nodes.Items) + 2
+// userPodStatus checks each user namespace's Gateways and the Envoy
+// pods that implement them.
+func userPodStatus(ctx context.Context, cl client.Client) ([]*componentHealth, error) {
+	report := []*componentHealth{}
+	nsPrefix := epicv1.ProductName + "-"
 
-	if len(pods.Items) != desiredCount {
-		return fmt.Errorf("incorrect system pod count: should be %d but is %d", desiredCount, len(pods.Items))
+	nsList := v1.NamespaceList{}
+	if err := cl.List(ctx, &nsList, client.MatchingLabels(epicv1.UserNSLabels)); err != nil {
+		return report, err
 	}
 
-	return nil
-}
+	for _, ns := range nsList.Items {
+		user := &componentHealth{Name: strings.TrimPrefix(ns.Name, nsPrefix)}
+		report = append(report, user)
 
-func userPodStatus(ctx context.Context, cl client.Client) error {
+		proxies := epicv1.GWProxyList{}
+		if err := cl.List(ctx, &proxies, &client.ListOptions{Namespace: ns.Name}); err != nil {
+			user.Problems = append(user.Problems, fmt.Sprintf("can't list gateways: %s", err))
+			continue
+		}
 
-	return nil
+		// The Account and Gateways report their own problems in their
+		// status conditions.
+		for _, kind := range []string{"Account", "GWProxy"} {
+			problems, err := conditionProblems(ctx, cl, kind, ns.Name)
+			if err != nil {
+				user.Problems = append(user.Problems, fmt.Sprintf("can't list %s objects: %s", kind, err))
+				continue
+			}
+			user.Problems = append(user.Problems, problems...)
+		}
+
+		pods := v1.PodList{}
+		if err := cl.List(ctx, &pods, &client.ListOptions{Namespace: ns.Name}); err != nil {
+			user.Problems = append(user.Problems, fmt.Sprintf("can't list pods: %s", err))
+			continue
+		}
+
+		// Each Gateway should have an external address and at least
+		// one healthy Envoy pod.
+		for _, proxy := range proxies.Items {
+			if len(proxy.Spec.Endpoints) == 0 {
+				user.Problems = append(user.Problems, fmt.Sprintf("gateway %s has no external address", proxy.Name))
+			}
+
+			envoys, ready := 0, 0
+			for i := range pods.Items {
+				if pods.Items[i].Labels[epicv1.OwningProxyLabel] == proxy.Name {
+					envoys++
+					if health.PodHealthy(&pods.Items[i]) {
+						ready++
+					}
+				}
+			}
+			switch {
+			case envoys == 0:
+				user.Problems = append(user.Problems, fmt.Sprintf("gateway %s has no Envoy pods", proxy.Name))
+			case ready == 0:
+				user.Problems = append(user.Problems, fmt.Sprintf("gateway %s has no healthy Envoy pods (0/%d)", proxy.Name, envoys))
+			}
+		}
+
+		podStatus(user, &pods)
+	}
+
+	return report, nil
 }
 
-// podStatus checks the pods that run in the epic namespace.
-func podStatus(ctx context.Context, cl client.Client, nodes *v1.NodeList, pods *v1.PodList) error {
-	// Are all of the pods running?
-	for _, pod := range pods.Items {
-		if pod.Status.Phase != v1.PodRunning {
-			return fmt.Errorf("pod %s is not healthy: %+v", pod.Name, pod.Status.Phase)
+// conditionProblems checks the status conditions of the EPIC custom
+// resources of kind in the namespace ns. They're read as unstructured
+// objects so we check whatever conditions the resource model reports.
+func conditionProblems(ctx context.Context, cl client.Client, kind string, ns string) ([]string, error) {
+	list := unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(epicv1.GroupVersion.WithKind(kind + "List"))
+	if err := cl.List(ctx, &list, &client.ListOptions{Namespace: ns}); err != nil {
+		return nil, err
+	}
+
+	problems := []string{}
+	for i := range list.Items {
+		conditions, err := health.ObjectConditions(&list.Items[i])
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
 		}
+		problems = append(problems, health.ConditionProblems(kind, list.Items[i].GetName(), conditions)...)
 	}
 
-	return nil
+	return problems, nil
+}
+
+// podStatus checks each of pods and records the results in c.
+func podStatus(c *componentHealth, pods *v1.PodList) {
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		c.Pods++
+		c.Restarts += health.PodRestarts(pod)
+		c.Problems = append(c.Problems, health.PodProblems(pod)...)
+	}
 }
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"epic-gateway.org/epicctl/internal/htpasswd"
+)
+
+func init() {
+	pwOpts := passwordOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "api-user username user-namespace",
+		Aliases: []string{"api-users"},
+		Short:   "Change an api-user's password",
+		Long: `Change the password of api-user username in a specified user namespace.
+
+Only that user's entry in the api-users secret is changed so the
+other users aren't affected, and the user can keep using the old
+password until the change is saved. The time of the change is
+recorded and shown by "epicctl get api-user".
+
+The password sources are the same as "epicctl create api-user".`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := pwOpts.validate(); err != nil {
+				return err
+			}
+
+			cl, err := getCRClient()
+			if err != nil {
+				return err
+			}
+
+			return updateAPIUser(rootCmd.Context(), cl, args[0], args[1], &pwOpts)
+		},
+	}
+	pwOpts.addFlags(cmd)
+	updateCmd.AddCommand(cmd)
+}
+
+// updateAPIUser sets a new password for apiUser in the user
+// namespace accountName.
+func updateAPIUser(ctx context.Context, cl client.Client, apiUser string, accountName string, pwOpts *passwordOptions) error {
+	// Check that the user exists before asking for a password.
+	secret, err := getAPIUsersSecret(ctx, cl, accountName)
+	if err != nil {
+		return err
+	}
+	users := parseAPIUsers(secret)
+	if _, found := users.Get(apiUser); !found {
+		return fmt.Errorf("api-user %s not found in user-namespace %s", apiUser, accountName)
+	}
+
+	hash, generated, err := pwOpts.passwordHash(apiUser)
+	if err != nil {
+		return err
+	}
+
+	err = modifyAPIUsers(ctx, cl, accountName, func(secret *v1.Secret, users *htpasswd.File) error {
+		if _, found := users.Get(apiUser); !found {
+			return fmt.Errorf("api-user %s was deleted from user-namespace %s", apiUser, accountName)
+		}
+		if _, err := users.Set(apiUser, hash); err != nil {
+			return err
+		}
+		setRotated(secret, apiUser, time.Now())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	createLog("api-user %s in user-namespace %s updated%s\n", apiUser, accountName, dryRunSuffix())
+	if generated != "" {
+		createLog("generated password (it won't be shown again): %s\n", generated)
+	}
+
+	return nil
+}
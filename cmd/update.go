@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// updateCmd is a container command for the subcommands that modify
+// existing resources.
+var updateCmd = &cobra.Command{
+	Use:     "update",
+	Aliases: []string{"u"},
+	Short:   "Updates resources",
+	Long:    `Updates existing resources in EPIC.`,
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+}
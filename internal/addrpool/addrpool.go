@@ -0,0 +1,63 @@
+// Package addrpool parses the address pools from which EPIC's
+// ServicePrefixes allocate Gateway addresses.
+package addrpool
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// Pool is a contiguous range of IP addresses.
+type Pool struct {
+	first net.IP
+	last  net.IP
+}
+
+// Parse parses pool, which is either a CIDR, e.g.,
+// "192.168.1.0/24", or a range, e.g., "192.168.1.10-192.168.1.20".
+func Parse(pool string) (Pool, error) {
+	if from, to, isRange := strings.Cut(pool, "-"); isRange {
+		first, last := net.ParseIP(strings.TrimSpace(from)), net.ParseIP(strings.TrimSpace(to))
+		if first == nil || last == nil {
+			return Pool{}, fmt.Errorf("can't parse %q as an address range", pool)
+		}
+		if (first.To4() == nil) != (last.To4() == nil) {
+			return Pool{}, fmt.Errorf("address range %q mixes IPv4 and IPv6", pool)
+		}
+		if bytes.Compare(first.To16(), last.To16()) > 0 {
+			return Pool{}, fmt.Errorf("address range %q ends before it starts", pool)
+		}
+		return Pool{first: first.To16(), last: last.To16()}, nil
+	}
+
+	_, subnet, err := net.ParseCIDR(strings.TrimSpace(pool))
+	if err != nil {
+		return Pool{}, fmt.Errorf("can't parse %q as an address range or CIDR", pool)
+	}
+	last := make(net.IP, len(subnet.IP))
+	for i := range subnet.IP {
+		last[i] = subnet.IP[i] | ^subnet.Mask[i]
+	}
+	return Pool{first: subnet.IP.To16(), last: last.To16()}, nil
+}
+
+// Size returns the number of addresses in p. IPv6 pools can be
+// bigger than a uint64 so the size saturates at math.MaxUint64.
+func (p Pool) Size() uint64 {
+	size := new(big.Int).Sub(new(big.Int).SetBytes(p.last), new(big.Int).SetBytes(p.first))
+	size.Add(size, big.NewInt(1))
+	if !size.IsUint64() {
+		return math.MaxUint64
+	}
+	return size.Uint64()
+}
+
+// Contains returns true if ip is in p.
+func (p Pool) Contains(ip net.IP) bool {
+	ip = ip.To16()
+	return ip != nil && bytes.Compare(ip, p.first) >= 0 && bytes.Compare(ip, p.last) <= 0
+}
@@ -0,0 +1,80 @@
+package addrpool
+
+import (
+	"math"
+	"net"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		pool     string
+		size     uint64
+		contains []string
+		excludes []string
+		wantErr  bool
+	}{
+		{
+			name:     "range",
+			pool:     "192.168.1.10-192.168.1.20",
+			size:     11,
+			contains: []string{"192.168.1.10", "192.168.1.15", "192.168.1.20"},
+			excludes: []string{"192.168.1.9", "192.168.1.21", "::ffff:192.168.2.15"},
+		},
+		{
+			name:     "single address",
+			pool:     "192.168.1.10-192.168.1.10",
+			size:     1,
+			contains: []string{"192.168.1.10"},
+			excludes: []string{"192.168.1.11"},
+		},
+		{
+			name:     "cidr",
+			pool:     "10.1.0.0/30",
+			size:     4,
+			contains: []string{"10.1.0.0", "10.1.0.3"},
+			excludes: []string{"10.1.0.4", "2001:db8::1"},
+		},
+		{
+			name:     "ipv6 cidr",
+			pool:     "2001:db8::/120",
+			size:     256,
+			contains: []string{"2001:db8::ff"},
+			excludes: []string{"2001:db8::100", "10.1.0.1"},
+		},
+		{name: "huge ipv6 cidr", pool: "2001:db8::/32", size: math.MaxUint64},
+		{name: "backwards range", pool: "192.168.1.20-192.168.1.10", wantErr: true},
+		{name: "mixed range", pool: "192.168.1.10-2001:db8::1", wantErr: true},
+		{name: "bad address", pool: "192.168.1.10-192.168.1", wantErr: true},
+		{name: "garbage", pool: "gatewayhttps", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, err := Parse(tt.pool)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, saw %#v", pool)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if size := pool.Size(); size != tt.size {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.size, size)
+			}
+			for _, addr := range tt.contains {
+				if !pool.Contains(net.ParseIP(addr)) {
+					t.Errorf("expected %s to contain %s", tt.pool, addr)
+				}
+			}
+			for _, addr := range tt.excludes {
+				if pool.Contains(net.ParseIP(addr)) {
+					t.Errorf("expected %s not to contain %s", tt.pool, addr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Package archive reads and writes the portable tar.gz archives that
+// epicctl uses to export and import user namespaces. Archives can be
+// sealed (encrypted) with a passphrase.
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// sealedMagic is the prefix of a sealed archive.
+const sealedMagic = "EPICCTL-SEALED-1\n"
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// Entry is a file in an archive.
+type Entry struct {
+	Name string
+	Data []byte
+}
+
+// Write writes entries to w as a tar.gz archive, in order. Each file
+// gets the modification time modTime.
+func Write(w io.Writer, entries []Entry, modTime time.Time) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, entry := range entries {
+		header := tar.Header{
+			Name:    entry.Name,
+			Mode:    0600,
+			Size:    int64(len(entry.Data)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(&header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(entry.Data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Read reads the entries from a tar.gz archive, in order. It ignores
+// everything but regular files.
+func Read(r io.Reader) ([]Entry, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a tar.gz archive: %w", err)
+	}
+	defer gz.Close()
+
+	entries := []Entry{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{Name: header.Name, Data: data})
+	}
+}
+
+// IsSealed returns true if data was produced by Seal.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(sealedMagic))
+}
+
+// Seal encrypts data with AES-256-GCM using a key derived from
+// passphrase with scrypt. The salt and nonce are stored in the
+// result.
+func Seal(data []byte, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := []byte(sealedMagic)
+	sealed = append(sealed, salt...)
+	sealed = append(sealed, nonce...)
+	return aead.Seal(sealed, nonce, data, []byte(sealedMagic)), nil
+}
+
+// Open decrypts data that was produced by Seal.
+func Open(data []byte, passphrase []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return nil, fmt.Errorf("archive is not sealed")
+	}
+	data = data[len(sealedMagic):]
+	if len(data) < saltSize {
+		return nil, fmt.Errorf("sealed archive is truncated")
+	}
+
+	aead, err := newAEAD(passphrase, data[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	data = data[saltSize:]
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed archive is truncated")
+	}
+
+	opened, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(sealedMagic))
+	if err != nil {
+		return nil, fmt.Errorf("can't decrypt archive (wrong passphrase?)")
+	}
+	return opened, nil
+}
+
+// newAEAD derives a key from passphrase and salt and returns an
+// AES-GCM cipher that uses it.
+func newAEAD(passphrase []byte, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
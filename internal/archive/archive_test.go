@@ -0,0 +1,93 @@
+package archive
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []Entry
+	}{
+		{name: "empty", entries: []Entry{}},
+		{
+			name: "ordered",
+			entries: []Entry{
+				{Name: "manifest.yaml", Data: []byte("namespace: acme\n")},
+				{Name: "objects/Secret/api-users.yaml", Data: []byte("kind: Secret\n")},
+				{Name: "objects/Account/acme.yaml", Data: []byte{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			if err := Write(&buf, tt.entries, time.Unix(0, 0)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			saw, err := Read(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(saw, tt.entries) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.entries, saw)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	if _, err := Read(bytes.NewReader([]byte("not an archive"))); err == nil {
+		t.Error("expected an error reading garbage")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	data := []byte("the tenant's secrets")
+
+	sealed, err := Seal(data, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !IsSealed(sealed) {
+		t.Error("sealed data isn't marked as sealed")
+	}
+	if IsSealed(data) {
+		t.Error("plain data is marked as sealed")
+	}
+	if bytes.Contains(sealed, data) {
+		t.Error("sealed data contains the plaintext")
+	}
+
+	tests := []struct {
+		name       string
+		sealed     []byte
+		passphrase string
+		expectErr  bool
+	}{
+		{name: "right passphrase", sealed: sealed, passphrase: "correct horse"},
+		{name: "wrong passphrase", sealed: sealed, passphrase: "battery staple", expectErr: true},
+		{name: "truncated", sealed: sealed[:len(sealedMagic)+4], passphrase: "correct horse", expectErr: true},
+		{name: "not sealed", sealed: data, passphrase: "correct horse", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saw, err := Open(tt.sealed, []byte(tt.passphrase))
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(saw, data) {
+				t.Errorf("\nexpected:\n%q\nsaw:\n%q", data, saw)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// abnormalWhenTrue are the condition types that describe a problem,
+// so they're bad when they're True. The rest are assumed to follow
+// the usual Kubernetes convention that True is good, e.g., Ready.
+var abnormalWhenTrue = map[string]bool{
+	"Degraded": true,
+	"Stalled":  true,
+	"Failed":   true,
+}
+
+// ObjectConditions returns the status.conditions of obj. Reading them
+// from an unstructured object works with any custom resource that
+// follows the Kubernetes conventions, whatever its Go types look
+// like.
+func ObjectConditions(obj *unstructured.Unstructured) ([]metav1.Condition, error) {
+	raw, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return []metav1.Condition{}, err
+	}
+
+	conditions := []metav1.Condition{}
+	for _, item := range raw {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return conditions, fmt.Errorf("%s %s has a malformed condition", obj.GetKind(), obj.GetName())
+		}
+		cond := metav1.Condition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(fields, &cond); err != nil {
+			return conditions, fmt.Errorf("%s %s has a malformed condition: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		conditions = append(conditions, cond)
+	}
+
+	return conditions, nil
+}
+
+// ConditionProblems describes the abnormal conditions of the object
+// kind/name, e.g., a Ready condition that's False. Conditions whose
+// status is Unknown aren't problems since they're usually on their
+// way to being True or False, and neither are Progressing conditions
+// since progress is neither good nor bad.
+func ConditionProblems(kind string, name string, conditions []metav1.Condition) []string {
+	problems := []string{}
+
+	for _, cond := range conditions {
+		if cond.Type == "Progressing" || cond.Status == metav1.ConditionUnknown {
+			continue
+		}
+		bad := cond.Status == metav1.ConditionFalse
+		if abnormalWhenTrue[cond.Type] {
+			bad = cond.Status == metav1.ConditionTrue
+		}
+		if !bad {
+			continue
+		}
+
+		problem := fmt.Sprintf("%s %s is %s=%s", kind, name, cond.Type, cond.Status)
+		if cond.Reason != "" {
+			problem += ": " + cond.Reason
+		}
+		if cond.Message != "" {
+			problem += ": " + cond.Message
+		}
+		problems = append(problems, problem)
+	}
+
+	return problems
+}
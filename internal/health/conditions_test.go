@@ -0,0 +1,102 @@
+package health
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestObjectConditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		obj      map[string]interface{}
+		expected []metav1.Condition
+		wantErr  bool
+	}{
+		{
+			name:     "no status",
+			obj:      map[string]interface{}{},
+			expected: []metav1.Condition{},
+		},
+		{
+			name: "conditions",
+			obj: map[string]interface{}{"status": map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "False", "reason": "NoAddress"},
+			}}},
+			expected: []metav1.Condition{{Type: "Ready", Status: metav1.ConditionFalse, Reason: "NoAddress"}},
+		},
+		{
+			name: "malformed",
+			obj: map[string]interface{}{"status": map[string]interface{}{"conditions": []interface{}{
+				"Ready",
+			}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions, err := ObjectConditions(&unstructured.Unstructured{Object: tt.obj})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, saw %#v", conditions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(conditions, tt.expected) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, conditions)
+			}
+		})
+	}
+}
+
+func TestConditionProblems(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []metav1.Condition
+		expected   []string
+	}{
+		{
+			name: "healthy",
+			conditions: []metav1.Condition{
+				{Type: "Ready", Status: metav1.ConditionTrue},
+				{Type: "Degraded", Status: metav1.ConditionFalse},
+				{Type: "Progressing", Status: metav1.ConditionFalse},
+			},
+			expected: []string{},
+		},
+		{
+			name: "not ready",
+			conditions: []metav1.Condition{
+				{Type: "Ready", Status: metav1.ConditionFalse, Reason: "NoAddress", Message: "no free addresses"},
+			},
+			expected: []string{"GWProxy web is Ready=False: NoAddress: no free addresses"},
+		},
+		{
+			name: "degraded",
+			conditions: []metav1.Condition{
+				{Type: "Degraded", Status: metav1.ConditionTrue},
+			},
+			expected: []string{"GWProxy web is Degraded=True"},
+		},
+		{
+			name: "unknown",
+			conditions: []metav1.Condition{
+				{Type: "Ready", Status: metav1.ConditionUnknown},
+			},
+			expected: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := ConditionProblems("GWProxy", "web", tt.conditions)
+			if !reflect.DeepEqual(problems, tt.expected) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package health evaluates the health of the Kubernetes objects that
+// make up an EPIC cluster.
+package health
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// PodProblems returns a description of each problem with pod. If the
+// pod is healthy then the slice is empty.
+func PodProblems(pod *v1.Pod) []string {
+	problems := []string{}
+
+	switch pod.Status.Phase {
+	case v1.PodSucceeded:
+		return problems
+	case v1.PodPending:
+		problems = append(problems, pendingReason(pod))
+	case v1.PodFailed, v1.PodUnknown:
+		problem := fmt.Sprintf("pod %s is %s", pod.Name, pod.Status.Phase)
+		if pod.Status.Reason != "" {
+			problem += ": " + pod.Status.Reason
+		}
+		problems = append(problems, problem)
+	}
+
+	for _, cs := range pod.Status.InitContainerStatuses {
+		if cs.State.Waiting != nil && isErrorReason(cs.State.Waiting.Reason) {
+			problems = append(problems, waitingProblem(pod, cs))
+		}
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		switch {
+		case cs.State.Waiting != nil && isErrorReason(cs.State.Waiting.Reason):
+			problems = append(problems, waitingProblem(pod, cs))
+		case pod.Status.Phase == v1.PodRunning && !cs.Ready:
+			problems = append(problems, fmt.Sprintf("pod %s container %s is not ready", pod.Name, cs.Name))
+		}
+	}
+
+	return problems
+}
+
+// PodRestarts returns the total number of times that pod's containers
+// have restarted.
+func PodRestarts(pod *v1.Pod) int32 {
+	var restarts int32
+	for _, cs := range pod.Status.ContainerStatuses {
+		restarts += cs.RestartCount
+	}
+	return restarts
+}
+
+// PodHealthy returns true if pod has no problems.
+func PodHealthy(pod *v1.Pod) bool {
+	return len(PodProblems(pod)) == 0
+}
+
+// PodStarting returns true if pod is Pending but none of its
+// containers have failed, i.e., it might just need more time to be
+// scheduled and pull its images.
+func PodStarting(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodPending {
+		return false
+	}
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, cs := range statuses {
+			if cs.State.Waiting != nil && isErrorReason(cs.State.Waiting.Reason) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pendingReason explains why pod is stuck in the Pending phase.
+func pendingReason(pod *v1.Pod) string {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodScheduled && cond.Status == v1.ConditionFalse {
+			if cond.Reason == v1.PodReasonUnschedulable {
+				return fmt.Sprintf("pod %s is unschedulable: %s", pod.Name, cond.Message)
+			}
+			return fmt.Sprintf("pod %s is pending: %s", pod.Name, cond.Reason)
+		}
+	}
+	return fmt.Sprintf("pod %s is pending", pod.Name)
+}
+
+// waitingProblem describes a container that is waiting to run.
+func waitingProblem(pod *v1.Pod, cs v1.ContainerStatus) string {
+	problem := fmt.Sprintf("pod %s container %s is waiting: %s", pod.Name, cs.Name, cs.State.Waiting.Reason)
+	if cs.RestartCount > 0 {
+		problem += fmt.Sprintf(" (%d restarts)", cs.RestartCount)
+	}
+	return problem
+}
+
+// isErrorReason returns true if reason indicates that a waiting
+// container is in trouble, as opposed to simply starting up.
+func isErrorReason(reason string) bool {
+	switch reason {
+	case "", "ContainerCreating", "PodInitializing":
+		return false
+	}
+	return true
+}
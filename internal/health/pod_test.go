@@ -0,0 +1,162 @@
+package health
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   v1.PodStatus
+		expected []string
+	}{
+		{
+			name: "healthy",
+			status: v1.PodStatus{
+				Phase:             v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{{Name: "c", Ready: true, RestartCount: 2}},
+			},
+			expected: []string{},
+		},
+		{
+			name:     "succeeded",
+			status:   v1.PodStatus{Phase: v1.PodSucceeded},
+			expected: []string{},
+		},
+		{
+			name: "not ready",
+			status: v1.PodStatus{
+				Phase:             v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{{Name: "c"}},
+			},
+			expected: []string{"pod p container c is not ready"},
+		},
+		{
+			name: "crashloop",
+			status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:         "c",
+					RestartCount: 5,
+					State:        v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				}},
+			},
+			expected: []string{"pod p container c is waiting: CrashLoopBackOff (5 restarts)"},
+		},
+		{
+			name: "unschedulable",
+			status: v1.PodStatus{
+				Phase: v1.PodPending,
+				Conditions: []v1.PodCondition{{
+					Type:    v1.PodScheduled,
+					Status:  v1.ConditionFalse,
+					Reason:  v1.PodReasonUnschedulable,
+					Message: "0/3 nodes are available",
+				}},
+			},
+			expected: []string{"pod p is unschedulable: 0/3 nodes are available"},
+		},
+		{
+			name: "image pull",
+			status: v1.PodStatus{
+				Phase: v1.PodPending,
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:  "c",
+					State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+				}},
+			},
+			expected: []string{"pod p is pending", "pod p container c is waiting: ImagePullBackOff"},
+		},
+		{
+			name: "creating",
+			status: v1.PodStatus{
+				Phase: v1.PodPending,
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:  "c",
+					State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+				}},
+			},
+			expected: []string{"pod p is pending"},
+		},
+		{
+			name:     "failed",
+			status:   v1.PodStatus{Phase: v1.PodFailed, Reason: "Evicted"},
+			expected: []string{"pod p is Failed: Evicted"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p"}, Status: tt.status}
+			problems := PodProblems(&pod)
+			if !reflect.DeepEqual(problems, tt.expected) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, problems)
+			}
+			if PodHealthy(&pod) != (len(tt.expected) == 0) {
+				t.Errorf("PodHealthy disagrees with PodProblems")
+			}
+		})
+	}
+}
+
+func TestPodStarting(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   v1.PodStatus
+		expected bool
+	}{
+		{name: "running", status: v1.PodStatus{Phase: v1.PodRunning}, expected: false},
+		{name: "pending", status: v1.PodStatus{Phase: v1.PodPending}, expected: true},
+		{
+			name: "creating",
+			status: v1.PodStatus{
+				Phase: v1.PodPending,
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:  "c",
+					State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+				}},
+			},
+			expected: true,
+		},
+		{
+			name: "image pull",
+			status: v1.PodStatus{
+				Phase: v1.PodPending,
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:  "c",
+					State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+				}},
+			},
+			expected: false,
+		},
+		{
+			name: "init crashloop",
+			status: v1.PodStatus{
+				Phase: v1.PodPending,
+				InitContainerStatuses: []v1.ContainerStatus{{
+					Name:  "i",
+					State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				}},
+			},
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p"}, Status: tt.status}
+			if starting := PodStarting(&pod); starting != tt.expected {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, starting)
+			}
+		})
+	}
+}
+
+func TestPodRestarts(t *testing.T) {
+	pod := v1.Pod{Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{RestartCount: 2}, {RestartCount: 3}}}}
+	if restarts := PodRestarts(&pod); restarts != 5 {
+		t.Errorf("expected 5 restarts, received %d", restarts)
+	}
+}
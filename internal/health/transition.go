@@ -0,0 +1,61 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// PodTransitions describes the ways in which pod's health changed
+// between old and new. If nothing interesting changed then the slice
+// is empty.
+func PodTransitions(old *v1.Pod, new *v1.Pod) []string {
+	transitions := []string{}
+
+	if restarts := PodRestarts(new); restarts > PodRestarts(old) {
+		transitions = append(transitions, fmt.Sprintf("restarted (%d restarts)", restarts))
+	}
+
+	oldProblems, newProblems := PodProblems(old), PodProblems(new)
+	switch {
+	case len(oldProblems) == 0 && len(newProblems) > 0:
+		transitions = append(transitions, "healthy -> degraded: "+strings.Join(newProblems, "; "))
+	case len(oldProblems) > 0 && len(newProblems) == 0:
+		transitions = append(transitions, "degraded -> healthy")
+	}
+
+	return transitions
+}
+
+// NodeReady returns true if node's Ready condition is True.
+func NodeReady(node *v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// NodeTransitions describes the ways in which node's health changed
+// between old and new. If nothing interesting changed then the slice
+// is empty.
+func NodeTransitions(old *v1.Node, new *v1.Node) []string {
+	transitions := []string{}
+
+	switch oldReady, newReady := NodeReady(old), NodeReady(new); {
+	case oldReady && !newReady:
+		transitions = append(transitions, "Ready -> NotReady")
+	case !oldReady && newReady:
+		transitions = append(transitions, "NotReady -> Ready")
+	}
+
+	if !old.Spec.Unschedulable && new.Spec.Unschedulable {
+		transitions = append(transitions, "cordoned")
+	} else if old.Spec.Unschedulable && !new.Spec.Unschedulable {
+		transitions = append(transitions, "uncordoned")
+	}
+
+	return transitions
+}
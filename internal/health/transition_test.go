@@ -0,0 +1,109 @@
+package health
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodTransitions(t *testing.T) {
+	healthy := runningPod("p", nil)
+	restarted := runningPod("p", nil)
+	restarted.Status.ContainerStatuses[0].RestartCount = 1
+	crashing := runningPod("p", nil)
+	crashing.Status.ContainerStatuses[0].Ready = false
+	crashing.Status.ContainerStatuses[0].RestartCount = 1
+	crashing.Status.ContainerStatuses[0].State.Waiting = &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}
+
+	tests := []struct {
+		name     string
+		old      v1.Pod
+		new      v1.Pod
+		expected []string
+	}{
+		{
+			name:     "no change",
+			old:      healthy,
+			new:      healthy,
+			expected: []string{},
+		},
+		{
+			name:     "restarted",
+			old:      healthy,
+			new:      restarted,
+			expected: []string{"restarted (1 restarts)"},
+		},
+		{
+			name:     "degraded",
+			old:      healthy,
+			new:      crashing,
+			expected: []string{"restarted (1 restarts)", "healthy -> degraded: pod p container c is waiting: CrashLoopBackOff (1 restarts)"},
+		},
+		{
+			name:     "recovered",
+			old:      crashing,
+			new:      restarted,
+			expected: []string{"degraded -> healthy"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transitions := PodTransitions(&tt.old, &tt.new)
+			if !reflect.DeepEqual(transitions, tt.expected) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, transitions)
+			}
+		})
+	}
+}
+
+func TestNodeTransitions(t *testing.T) {
+	node := func(ready v1.ConditionStatus, cordoned bool) v1.Node {
+		return v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "n"},
+			Spec:       v1.NodeSpec{Unschedulable: cordoned},
+			Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: ready}}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		old      v1.Node
+		new      v1.Node
+		expected []string
+	}{
+		{
+			name:     "no change",
+			old:      node(v1.ConditionTrue, false),
+			new:      node(v1.ConditionTrue, false),
+			expected: []string{},
+		},
+		{
+			name:     "not ready",
+			old:      node(v1.ConditionTrue, false),
+			new:      node(v1.ConditionUnknown, false),
+			expected: []string{"Ready -> NotReady"},
+		},
+		{
+			name:     "ready and uncordoned",
+			old:      node(v1.ConditionFalse, true),
+			new:      node(v1.ConditionTrue, false),
+			expected: []string{"NotReady -> Ready", "uncordoned"},
+		},
+		{
+			name:     "cordoned",
+			old:      node(v1.ConditionTrue, false),
+			new:      node(v1.ConditionTrue, true),
+			expected: []string{"cordoned"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transitions := NodeTransitions(&tt.old, &tt.new)
+			if !reflect.DeepEqual(transitions, tt.expected) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, transitions)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DaemonSetProblems compares the number of healthy pods with the
+// number that ds wants scheduled. pods should include (at least) the
+// pods in ds's namespace. If ds is short then the problem explains
+// why.
+func DaemonSetProblems(ds *appsv1.DaemonSet, pods []v1.Pod) ([]string, error) {
+	owned, err := SelectPods(ds.Spec.Selector, pods)
+	if err != nil {
+		return nil, err
+	}
+
+	return workloadProblems("daemonset", ds.Name, ds.Status.DesiredNumberScheduled, owned), nil
+}
+
+// DeploymentProblems compares the number of healthy pods with the
+// number of replicas that dep specifies. pods should include (at
+// least) the pods in dep's namespace. If dep is short then the
+// problem explains why.
+func DeploymentProblems(dep *appsv1.Deployment, pods []v1.Pod) ([]string, error) {
+	owned, err := SelectPods(dep.Spec.Selector, pods)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replicas defaults to 1 if it's not set.
+	var desired int32 = 1
+	if dep.Spec.Replicas != nil {
+		desired = *dep.Spec.Replicas
+	}
+
+	return workloadProblems("deployment", dep.Name, desired, owned), nil
+}
+
+// SelectPods returns the pods that match selector.
+func SelectPods(selector *metav1.LabelSelector, pods []v1.Pod) ([]v1.Pod, error) {
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := []v1.Pod{}
+	for _, pod := range pods {
+		if sel.Matches(labels.Set(pod.Labels)) {
+			selected = append(selected, pod)
+		}
+	}
+
+	return selected, nil
+}
+
+// workloadProblems compares the number of healthy pods in owned with
+// desired. If there are fewer healthy pods than desired then the
+// problem includes the reasons why the unhealthy pods are unhealthy.
+// Even if there are enough healthy pods, e.g., during a rollout, the
+// pods that have failed are problems, although the ones that are just
+// starting aren't.
+func workloadProblems(kind string, name string, desired int32, owned []v1.Pod) []string {
+	var ready int32
+	reasons := []string{}
+	failures := []string{}
+	for i := range owned {
+		problems := PodProblems(&owned[i])
+		if len(problems) == 0 {
+			ready++
+			continue
+		}
+		reasons = append(reasons, problems...)
+		if !PodStarting(&owned[i]) {
+			failures = append(failures, problems...)
+		}
+	}
+
+	if ready >= desired {
+		if len(failures) == 0 {
+			return []string{}
+		}
+		return []string{fmt.Sprintf("%s %s has %d/%d ready pods but others have failed: %s", kind, name, ready, desired, strings.Join(failures, "; "))}
+	}
+
+	switch missing := desired - int32(len(owned)); {
+	case missing == 1:
+		reasons = append(reasons, "1 pod has not been created")
+	case missing > 1:
+		reasons = append(reasons, fmt.Sprintf("%d pods have not been created", missing))
+	}
+
+	return []string{fmt.Sprintf("%s %s has %d/%d ready pods: %s", kind, name, ready, desired, strings.Join(reasons, "; "))}
+}
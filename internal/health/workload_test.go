@@ -0,0 +1,153 @@
+package health
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var (
+	selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "agent"}}
+	agent    = map[string]string{"app": "agent"}
+)
+
+func runningPod(name string, labels map[string]string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Status: v1.PodStatus{
+			Phase:             v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{{Name: "c", Ready: true}},
+		},
+	}
+}
+
+func TestDaemonSetProblems(t *testing.T) {
+	unschedulable := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent-c", Labels: agent},
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+			Conditions: []v1.PodCondition{{
+				Type:    v1.PodScheduled,
+				Status:  v1.ConditionFalse,
+				Reason:  v1.PodReasonUnschedulable,
+				Message: "node is tainted",
+			}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		desired  int32
+		pods     []v1.Pod
+		expected []string
+	}{
+		{
+			name:     "healthy",
+			desired:  2,
+			pods:     []v1.Pod{runningPod("agent-a", agent), runningPod("agent-b", agent), runningPod("other", nil)},
+			expected: []string{},
+		},
+		{
+			name:     "unschedulable",
+			desired:  3,
+			pods:     []v1.Pod{runningPod("agent-a", agent), runningPod("agent-b", agent), unschedulable},
+			expected: []string{"daemonset agent has 2/3 ready pods: pod agent-c is unschedulable: node is tainted"},
+		},
+		{
+			name:     "missing",
+			desired:  2,
+			pods:     []v1.Pod{runningPod("agent-a", agent), runningPod("other", nil)},
+			expected: []string{"daemonset agent has 1/2 ready pods: 1 pod has not been created"},
+		},
+		{
+			name:     "two missing",
+			desired:  3,
+			pods:     []v1.Pod{runningPod("agent-a", agent)},
+			expected: []string{"daemonset agent has 1/3 ready pods: 2 pods have not been created"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "agent"},
+				Spec:       appsv1.DaemonSetSpec{Selector: selector},
+				Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: tt.desired},
+			}
+			problems, err := DaemonSetProblems(&ds, tt.pods)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(problems, tt.expected) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, problems)
+			}
+		})
+	}
+}
+
+func TestDeploymentProblems(t *testing.T) {
+	pulling := runningPod("agent-b", agent)
+	pulling.Status.Phase = v1.PodPending
+	pulling.Status.ContainerStatuses[0].Ready = false
+	pulling.Status.ContainerStatuses[0].State.Waiting = &v1.ContainerStateWaiting{Reason: "ErrImagePull"}
+	crashing := runningPod("agent-c", agent)
+	crashing.Status.ContainerStatuses[0].Ready = false
+	crashing.Status.ContainerStatuses[0].RestartCount = 3
+	crashing.Status.ContainerStatuses[0].State.Waiting = &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}
+	creating := runningPod("agent-d", agent)
+	creating.Status.Phase = v1.PodPending
+	creating.Status.ContainerStatuses[0].Ready = false
+	creating.Status.ContainerStatuses[0].State.Waiting = &v1.ContainerStateWaiting{Reason: "ContainerCreating"}
+
+	tests := []struct {
+		name     string
+		replicas *int32
+		pods     []v1.Pod
+		expected []string
+	}{
+		{
+			name:     "default replicas",
+			pods:     []v1.Pod{runningPod("agent-a", agent)},
+			expected: []string{},
+		},
+		{
+			name:     "scaled",
+			replicas: pointer.Int32Ptr(2),
+			pods:     []v1.Pod{runningPod("agent-a", agent), pulling},
+			expected: []string{"deployment agent has 1/2 ready pods: pod agent-b is pending; pod agent-b container c is waiting: ErrImagePull"},
+		},
+		{
+			name:     "rollout failing",
+			pods:     []v1.Pod{runningPod("agent-a", agent), crashing},
+			expected: []string{"deployment agent has 1/1 ready pods but others have failed: pod agent-c container c is waiting: CrashLoopBackOff (3 restarts)"},
+		},
+		{
+			name:     "rollout starting",
+			pods:     []v1.Pod{runningPod("agent-a", agent), creating},
+			expected: []string{},
+		},
+		{
+			name:     "scaled to zero",
+			replicas: pointer.Int32Ptr(0),
+			expected: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dep := appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "agent"},
+				Spec:       appsv1.DeploymentSpec{Selector: selector, Replicas: tt.replicas},
+			}
+			problems, err := DeploymentProblems(&dep, tt.pods)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(problems, tt.expected) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// Package htpasswd reads and writes the htpasswd files that Contour
+// uses for basic authentication, e.g., the "auth" data in a user
+// namespace's api-users Secret.
+//
+// Comments, blank lines, lines that can't be parsed and the order of
+// the entries are preserved
+// so a file can be read, modified and written back without
+// disturbing the parts that weren't changed.
+package htpasswd
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algorithm is a password hashing algorithm.
+type Algorithm string
+
+const (
+	Bcrypt  Algorithm = "bcrypt"
+	APR1    Algorithm = "apr1"
+	SHA     Algorithm = "sha"
+	Unknown Algorithm = "unknown"
+)
+
+// Entry is a user and the hash of their password.
+type Entry struct {
+	User string
+	Hash string
+}
+
+// Algorithm returns the algorithm that was used to make e's hash.
+func (e Entry) Algorithm() Algorithm {
+	return DetectAlgorithm(e.Hash)
+}
+
+// Verify returns true if password matches e's hash. Only bcrypt and
+// sha hashes can be verified, so other hashes (including ones that
+// Parse didn't recognize) are errors.
+func (e Entry) Verify(password string) (bool, error) {
+	switch e.Algorithm() {
+	case Bcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(e.Hash), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	case SHA:
+		sum := sha1.Sum([]byte(password))
+		expected := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(expected), []byte(e.Hash)) == 1, nil
+	}
+	return false, fmt.Errorf("can't verify %s hashes", e.Algorithm())
+}
+
+// line is one line of a file. If entry is nil then the line is a
+// comment, a blank or a line that couldn't be parsed, and raw holds
+// its text. problem explains why a line couldn't be parsed.
+type line struct {
+	entry   *Entry
+	raw     string
+	problem string
+}
+
+// File is a parsed htpasswd file.
+type File struct {
+	lines []line
+}
+
+// DetectAlgorithm returns the algorithm that was used to make hash.
+func DetectAlgorithm(hash string) Algorithm {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return Bcrypt
+	case strings.HasPrefix(hash, "$apr1$"):
+		return APR1
+	case strings.HasPrefix(hash, "{SHA}"):
+		return SHA
+	}
+	return Unknown
+}
+
+// ValidateUser checks that user can be stored in a file.
+func ValidateUser(user string) error {
+	if user == "" {
+		return fmt.Errorf("user name is empty")
+	}
+	if strings.ContainsAny(user, ": \t\r\n#") {
+		return fmt.Errorf("user name %q can't contain colons, whitespace or #", user)
+	}
+	return nil
+}
+
+// ValidateHash checks that hash was made by a supported algorithm.
+func ValidateHash(hash string) error {
+	if DetectAlgorithm(hash) == Unknown {
+		return fmt.Errorf("unsupported password hash, must be bcrypt, apr1 or sha")
+	}
+	return nil
+}
+
+// New returns an empty file.
+func New() *File {
+	return &File{lines: []line{}}
+}
+
+// Parse parses the contents of an htpasswd file. It's lenient so a
+// file that another tool wrote can still be modified: entries with
+// unsupported hashes are kept as-is (they can't be verified but they
+// can be deleted or replaced), and malformed lines and duplicate
+// users are kept as opaque lines that Problems reports.
+func Parse(data []byte) *File {
+	f := New()
+	users := map[string]bool{}
+
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return f
+	}
+
+	for i, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			f.lines = append(f.lines, line{raw: raw})
+			continue
+		}
+
+		user, hash, found := strings.Cut(trimmed, ":")
+		if !found {
+			f.lines = append(f.lines, line{raw: raw, problem: fmt.Sprintf("line %d: missing \":\" separator", i+1)})
+			continue
+		}
+		if err := ValidateUser(user); err != nil {
+			f.lines = append(f.lines, line{raw: raw, problem: fmt.Sprintf("line %d: %s", i+1, err)})
+			continue
+		}
+		if users[user] {
+			f.lines = append(f.lines, line{raw: raw, problem: fmt.Sprintf("line %d: duplicate user %s", i+1, user)})
+			continue
+		}
+		users[user] = true
+
+		f.lines = append(f.lines, line{entry: &Entry{User: user, Hash: hash}})
+	}
+
+	return f
+}
+
+// Problems describes the lines in f that couldn't be parsed.
+func (f *File) Problems() []string {
+	problems := []string{}
+	for _, l := range f.lines {
+		if l.problem != "" {
+			problems = append(problems, l.problem)
+		}
+	}
+	return problems
+}
+
+// Entries returns the entries in f, in order.
+func (f *File) Entries() []Entry {
+	entries := []Entry{}
+	for _, l := range f.lines {
+		if l.entry != nil {
+			entries = append(entries, *l.entry)
+		}
+	}
+	return entries
+}
+
+// Users returns the names of the users in f, in order.
+func (f *File) Users() []string {
+	users := []string{}
+	for _, entry := range f.Entries() {
+		users = append(users, entry.User)
+	}
+	return users
+}
+
+// Get returns user's entry, and whether it was found.
+func (f *File) Get(user string) (Entry, bool) {
+	if l := f.find(user); l != nil {
+		return *l.entry, true
+	}
+	return Entry{}, false
+}
+
+// Set sets user's hash. If user is already in f then their entry is
+// updated in place, otherwise it's added to the end. It returns true
+// if the user was added.
+func (f *File) Set(user string, hash string) (bool, error) {
+	if err := ValidateUser(user); err != nil {
+		return false, err
+	}
+	if err := ValidateHash(hash); err != nil {
+		return false, err
+	}
+
+	if l := f.find(user); l != nil {
+		l.entry.Hash = hash
+		return false, nil
+	}
+
+	f.lines = append(f.lines, line{entry: &Entry{User: user, Hash: hash}})
+	return true, nil
+}
+
+// Delete removes user from f. It returns true if user was found.
+func (f *File) Delete(user string) bool {
+	for i, l := range f.lines {
+		if l.entry != nil && l.entry.User == user {
+			f.lines = append(f.lines[:i], f.lines[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Bytes returns the contents of f in htpasswd format. Each line,
+// including the last, ends with a newline.
+func (f *File) Bytes() []byte {
+	out := strings.Builder{}
+	for _, l := range f.lines {
+		if l.entry != nil {
+			out.WriteString(l.entry.User + ":" + l.entry.Hash)
+		} else {
+			out.WriteString(l.raw)
+		}
+		out.WriteString("\n")
+	}
+	return []byte(out.String())
+}
+
+// find returns user's line, or nil if user isn't in f.
+func (f *File) find(user string) *line {
+	for i := range f.lines {
+		if f.lines[i].entry != nil && f.lines[i].entry.User == user {
+			return &f.lines[i]
+		}
+	}
+	return nil
+}
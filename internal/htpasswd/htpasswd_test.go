@@ -0,0 +1,188 @@
+package htpasswd
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	bcryptHash = "$2y$10$ZGGm5kBY5tg7BU1BNkXlDuZ7YBjXsfYd1jDNzLd0k0uJ7JXk1K4lW"
+	apr1Hash   = "$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/"
+	shaHash    = "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g="
+)
+
+func TestDetectAlgorithm(t *testing.T) {
+	tests := []struct {
+		name     string
+		hash     string
+		expected Algorithm
+	}{
+		{name: "bcrypt 2y", hash: bcryptHash, expected: Bcrypt},
+		{name: "bcrypt 2a", hash: "$2a$" + bcryptHash[4:], expected: Bcrypt},
+		{name: "bcrypt 2b", hash: "$2b$" + bcryptHash[4:], expected: Bcrypt},
+		{name: "apr1", hash: apr1Hash, expected: APR1},
+		{name: "sha", hash: shaHash, expected: SHA},
+		{name: "crypt", hash: "rl0P5tQ2fdBRE", expected: Unknown},
+		{name: "empty", hash: "", expected: Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if saw := DetectAlgorithm(tt.hash); saw != tt.expected {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, saw)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		expected      []Entry
+		expectedBytes string
+		expectedProbs []string
+	}{
+		{
+			name:          "empty",
+			data:          "",
+			expected:      []Entry{},
+			expectedBytes: "",
+		},
+		{
+			name:          "round trip",
+			data:          "# API users\nalice:" + bcryptHash + "\n\nbob:" + apr1Hash + "\ncarol:" + shaHash + "\n",
+			expected:      []Entry{{User: "alice", Hash: bcryptHash}, {User: "bob", Hash: apr1Hash}, {User: "carol", Hash: shaHash}},
+			expectedBytes: "# API users\nalice:" + bcryptHash + "\n\nbob:" + apr1Hash + "\ncarol:" + shaHash + "\n",
+		},
+		{
+			name:          "normalized",
+			data:          "alice:" + bcryptHash + "\r\n  bob:" + apr1Hash,
+			expected:      []Entry{{User: "alice", Hash: bcryptHash}, {User: "bob", Hash: apr1Hash}},
+			expectedBytes: "alice:" + bcryptHash + "\nbob:" + apr1Hash + "\n",
+		},
+		{
+			name:          "no separator",
+			data:          "alice\nbob:" + shaHash + "\n",
+			expected:      []Entry{{User: "bob", Hash: shaHash}},
+			expectedBytes: "alice\nbob:" + shaHash + "\n",
+			expectedProbs: []string{`line 1: missing ":" separator`},
+		},
+		{
+			name:          "no user",
+			data:          "# x\n:" + bcryptHash + "\n",
+			expected:      []Entry{},
+			expectedBytes: "# x\n:" + bcryptHash + "\n",
+			expectedProbs: []string{"line 2: user name is empty"},
+		},
+		{
+			name:          "plaintext",
+			data:          "alice:secret\n",
+			expected:      []Entry{{User: "alice", Hash: "secret"}},
+			expectedBytes: "alice:secret\n",
+		},
+		{
+			name:          "duplicate",
+			data:          "alice:" + bcryptHash + "\nalice:" + shaHash + "\n",
+			expected:      []Entry{{User: "alice", Hash: bcryptHash}},
+			expectedBytes: "alice:" + bcryptHash + "\nalice:" + shaHash + "\n",
+			expectedProbs: []string{"line 2: duplicate user alice"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Parse([]byte(tt.data))
+			if tt.expectedProbs == nil {
+				tt.expectedProbs = []string{}
+			}
+			if saw := f.Problems(); !reflect.DeepEqual(saw, tt.expectedProbs) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expectedProbs, saw)
+			}
+			if saw := f.Entries(); !reflect.DeepEqual(saw, tt.expected) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, saw)
+			}
+			if saw := string(f.Bytes()); saw != tt.expectedBytes {
+				t.Errorf("\nexpected:\n%q\nsaw:\n%q", tt.expectedBytes, saw)
+			}
+		})
+	}
+}
+
+func TestModify(t *testing.T) {
+	f := Parse([]byte("# API users\nalice:" + bcryptHash + "\nbob:" + apr1Hash + "\ncrypt:rl0P5tQ2fdBRE\nbroken line\n"))
+
+	// Updating a user keeps their position.
+	if added, err := f.Set("alice", shaHash); added || err != nil {
+		t.Errorf("updating alice: added %v, err %v", added, err)
+	}
+	// New users go at the end.
+	if added, err := f.Set("carol", bcryptHash); !added || err != nil {
+		t.Errorf("adding carol: added %v, err %v", added, err)
+	}
+	if _, err := f.Set("dave", "plaintext"); err == nil {
+		t.Error("expected an error setting a plaintext password")
+	}
+	if _, err := f.Set("d:ave", bcryptHash); err == nil {
+		t.Error("expected an error setting a user with a colon")
+	}
+	if !f.Delete("bob") {
+		t.Error("bob wasn't deleted")
+	}
+	if f.Delete("bob") {
+		t.Error("bob was deleted twice")
+	}
+	// Users with unsupported hashes can still be deleted.
+	if !f.Delete("crypt") {
+		t.Error("crypt wasn't deleted")
+	}
+
+	if entry, found := f.Get("alice"); !found || entry.Algorithm() != SHA {
+		t.Errorf("alice: found %v, entry %#v", found, entry)
+	}
+	if _, found := f.Get("bob"); found {
+		t.Error("bob found after delete")
+	}
+	if saw, expected := f.Users(), []string{"alice", "carol"}; !reflect.DeepEqual(saw, expected) {
+		t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", expected, saw)
+	}
+
+	// Lines that couldn't be parsed are kept.
+	expected := "# API users\nalice:" + shaHash + "\nbroken line\ncarol:" + bcryptHash + "\n"
+	if saw := string(f.Bytes()); saw != expected {
+		t.Errorf("\nexpected:\n%q\nsaw:\n%q", expected, saw)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter22"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		entry     Entry
+		password  string
+		expected  bool
+		expectErr bool
+	}{
+		{name: "bcrypt match", entry: Entry{User: "alice", Hash: string(hash)}, password: "hunter22", expected: true},
+		{name: "bcrypt mismatch", entry: Entry{User: "alice", Hash: string(hash)}, password: "hunter23", expected: false},
+		{name: "sha match", entry: Entry{User: "bob", Hash: shaHash}, password: "password", expected: true},
+		{name: "sha mismatch", entry: Entry{User: "bob", Hash: shaHash}, password: "Password", expected: false},
+		{name: "apr1", entry: Entry{User: "carol", Hash: apr1Hash}, password: "password", expectErr: true},
+		{name: "unknown", entry: Entry{User: "dave", Hash: "rl0P5tQ2fdBRE"}, password: "password", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saw, err := tt.entry.Verify(tt.password)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error %v, saw %v", tt.expectErr, err)
+			}
+			if saw != tt.expected {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, saw)
+			}
+		})
+	}
+}
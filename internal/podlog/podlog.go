@@ -0,0 +1,137 @@
+// Package podlog parses and filters the lines of Kubernetes pod logs.
+package podlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Line is one line of a pod's log.
+type Line struct {
+	// Time is when the line was logged.
+	Time time.Time
+
+	// Source identifies the pod (or container) that logged the line.
+	Source string
+
+	// Text is the content of the line, without the timestamp.
+	Text string
+}
+
+// ParseTimestamped parses a log line that was read using
+// PodLogOptions.Timestamps, i.e., one that starts with an RFC3339
+// timestamp followed by a space.
+func ParseTimestamped(source string, raw string) (Line, error) {
+	stamp, text, found := strings.Cut(raw, " ")
+	if !found {
+		return Line{}, fmt.Errorf("no timestamp in log line %q", raw)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		return Line{}, err
+	}
+
+	return Line{Time: t, Source: source, Text: text}, nil
+}
+
+// accountKeys are the words that can precede an account name in a
+// log line, e.g., in a URL path ("/accounts/acme/") or a structured
+// field ("account=acme" or {"account":"acme"}).
+var accountKeys = map[string]bool{
+	"account":  true,
+	"accounts": true,
+}
+
+// MatchesAccount returns true if text refers to the account, i.e.,
+// it contains the account's namespace name as a whole word, or the
+// account's name as a whole word immediately after an account key.
+// Unlike a substring check this doesn't match other accounts whose
+// names share a prefix or suffix with this one.
+func MatchesAccount(text string, account string, namespace string) bool {
+	words := strings.FieldsFunc(text, isDelimiter)
+	for i, word := range words {
+		if word == namespace {
+			return true
+		}
+		if word == account && i > 0 && accountKeys[strings.ToLower(words[i-1])] {
+			return true
+		}
+	}
+	return false
+}
+
+// isDelimiter returns true if r separates words. Account and
+// namespace names can contain letters, digits and dashes, so
+// everything else, including dots and underscores, is a delimiter.
+func isDelimiter(r rune) bool {
+	return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-')
+}
+
+// Merger merges lines from several logs into timestamp order.
+// Lines from different pods arrive at slightly different times so
+// the Merger holds each line for a short delay before releasing it,
+// giving lines from the other pods a chance to catch up. It's safe
+// for concurrent use.
+type Merger struct {
+	// Delay is how long each line is held.
+	Delay time.Duration
+
+	mu      sync.Mutex
+	pending []pendingLine
+}
+
+type pendingLine struct {
+	Line
+	arrived time.Time
+}
+
+// Add adds a line that arrived at time now.
+func (m *Merger) Add(line Line, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pending = append(m.pending, pendingLine{Line: line, arrived: now})
+}
+
+// Ready removes and returns, in timestamp order, the lines that have
+// been held for at least Delay as of time now.
+func (m *Merger) Ready(now time.Time) []Line {
+	return m.release(func(p pendingLine) bool {
+		return !p.arrived.After(now.Add(-m.Delay))
+	})
+}
+
+// Flush removes and returns all of the pending lines in timestamp
+// order.
+func (m *Merger) Flush() []Line {
+	return m.release(func(pendingLine) bool { return true })
+}
+
+// release removes and returns, in timestamp order, the pending lines
+// for which ready returns true.
+func (m *Merger) release(ready func(pendingLine) bool) []Line {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := []Line{}
+	kept := m.pending[:0]
+	for _, p := range m.pending {
+		if ready(p) {
+			out = append(out, p.Line)
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	m.pending = kept
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Time.Before(out[j].Time)
+	})
+
+	return out
+}
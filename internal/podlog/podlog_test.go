@@ -0,0 +1,87 @@
+package podlog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamped(t *testing.T) {
+	line, err := ParseTimestamped("ws-1", "2022-08-01T10:11:12.123456789Z GET /api/epic/accounts/acme/ 200")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Line{
+		Time:   time.Date(2022, 8, 1, 10, 11, 12, 123456789, time.UTC),
+		Source: "ws-1",
+		Text:   "GET /api/epic/accounts/acme/ 200",
+	}
+	if !reflect.DeepEqual(line, expected) {
+		t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", expected, line)
+	}
+
+	for _, bad := range []string{"no-timestamp", "yesterday something happened"} {
+		if _, err := ParseTimestamped("ws-1", bad); err == nil {
+			t.Errorf("expected error parsing %q", bad)
+		}
+	}
+}
+
+func TestMatchesAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected bool
+	}{
+		{name: "url path", text: "GET /api/epic/accounts/acme/groups/web/services 200", expected: true},
+		{name: "url path end", text: "GET /api/epic/accounts/acme", expected: true},
+		{name: "logfmt", text: `level=info account=acme msg="created"`, expected: true},
+		{name: "json", text: `{"level":"info","account":"acme","msg":"created"}`, expected: true},
+		{name: "namespace", text: "reconciling epic-acme/gateway", expected: true},
+		{name: "end of sentence", text: "deleted namespace epic-acme.", expected: true},
+		{name: "qualified name", text: "resolved envoy.epic-acme.svc", expected: true},
+		{name: "underscore", text: "reconciling epic-acme_gateway", expected: true},
+		{name: "prefix", text: "GET /api/epic/accounts/acme-corp/groups/web 200", expected: false},
+		{name: "suffix", text: "GET /api/epic/accounts/notacme/groups/web 200", expected: false},
+		{name: "other namespace", text: "reconciling epic-acme-corp/gateway", expected: false},
+		{name: "bare word", text: "acme is mentioned but not as an account", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matched := MatchesAccount(tt.text, "acme", "epic-acme"); matched != tt.expected {
+				t.Errorf("expected %t, received %t", tt.expected, matched)
+			}
+		})
+	}
+}
+
+func TestMerger(t *testing.T) {
+	base := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	line := func(offset time.Duration, source string) Line {
+		return Line{Time: base.Add(offset), Source: source, Text: source}
+	}
+
+	m := Merger{Delay: time.Second}
+	m.Add(line(2*time.Second, "b"), base)
+	m.Add(line(1*time.Second, "a"), base.Add(500*time.Millisecond))
+	m.Add(line(3*time.Second, "c"), base.Add(2*time.Second))
+
+	// Nothing has been held long enough yet.
+	if ready := m.Ready(base.Add(900 * time.Millisecond)); len(ready) != 0 {
+		t.Errorf("expected no lines, saw %#v", ready)
+	}
+
+	// The first two lines are ready, and they're released in timestamp
+	// order, not arrival order.
+	ready := m.Ready(base.Add(1500 * time.Millisecond))
+	if expected := []Line{line(1*time.Second, "a"), line(2*time.Second, "b")}; !reflect.DeepEqual(ready, expected) {
+		t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", expected, ready)
+	}
+
+	if flushed := m.Flush(); !reflect.DeepEqual(flushed, []Line{line(3*time.Second, "c")}) {
+		t.Errorf("unexpected flush: %#v", flushed)
+	}
+	if flushed := m.Flush(); len(flushed) != 0 {
+		t.Errorf("expected empty flush, saw %#v", flushed)
+	}
+}
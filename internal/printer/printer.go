@@ -0,0 +1,163 @@
+// Package printer renders epicctl command output in the formats
+// selected by the "--output" flag.
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/olekukonko/tablewriter"
+	"sigs.k8s.io/yaml"
+)
+
+// Format is an output format. The zero value is the default
+// human-readable format.
+type Format string
+
+const (
+	// Default is a human-readable table.
+	Default Format = ""
+	// Wide is a table with additional columns.
+	Wide Format = "wide"
+	// Name prints only the name of each item, one per line.
+	Name Format = "name"
+	// JSON prints the items as a JSON array.
+	JSON Format = "json"
+	// YAML prints the items as a YAML sequence.
+	YAML Format = "yaml"
+)
+
+// FlagUsage describes the supported formats. It's intended to be
+// used as the "--output" flag's usage string.
+const FlagUsage = "output format: json|yaml|wide|name"
+
+// ParseFormat validates s and converts it to a Format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Default, Wide, Name, JSON, YAML:
+		return f, nil
+	}
+	return Default, fmt.Errorf("unknown output format %q, must be one of json|yaml|wide|name", s)
+}
+
+// Human returns true if f is one of the table formats, i.e., it's
+// meant for people and not programs.
+func (f Format) Human() bool {
+	return f == Default || f == Wide
+}
+
+// Column is a table column.
+type Column struct {
+	Header string
+
+	// Wide columns are only shown in "wide" output.
+	Wide bool
+}
+
+// Row is one item in a Table.
+type Row struct {
+	// Name is what's output in "name" format.
+	Name string
+
+	// Cells are the table cells, one per Column.
+	Cells []string
+
+	// Object is what's output in "json" and "yaml" formats. It should
+	// have json struct tags so the field names are stable.
+	Object interface{}
+}
+
+// Table is a list of items that can be output in any Format.
+type Table struct {
+	Columns []Column
+	Rows    []Row
+}
+
+// Append adds a row to the table.
+func (t *Table) Append(name string, object interface{}, cells ...string) {
+	t.Rows = append(t.Rows, Row{Name: name, Cells: cells, Object: object})
+}
+
+// Print writes t to w in format f.
+func (t Table) Print(w io.Writer, f Format) error {
+	switch f {
+	case Default, Wide:
+		t.printTable(w, f == Wide)
+		return nil
+	case Name:
+		for _, row := range t.Rows {
+			if _, err := fmt.Fprintln(w, row.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Make sure that an empty table is output as an empty list and
+	// not as "null".
+	objects := make([]interface{}, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		objects = append(objects, row.Object)
+	}
+	return PrintObject(w, f, objects)
+}
+
+// printTable renders t as a text table, including the wide columns
+// if wide is true.
+func (t Table) printTable(w io.Writer, wide bool) {
+	table := tablewriter.NewWriter(w)
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	table.SetBorder(false)
+
+	headers := []string{}
+	for _, col := range t.Columns {
+		if wide || !col.Wide {
+			headers = append(headers, col.Header)
+		}
+	}
+	table.SetHeader(headers)
+
+	for _, row := range t.Rows {
+		cells := []string{}
+		for i, col := range t.Columns {
+			if wide || !col.Wide {
+				cell := ""
+				if i < len(row.Cells) {
+					cell = row.Cells[i]
+				}
+				cells = append(cells, cell)
+			}
+		}
+		table.Append(cells)
+	}
+
+	table.Render()
+}
+
+// PrintObject writes obj to w in one of the machine-readable
+// formats, i.e., JSON or YAML.
+func PrintObject(w io.Writer, f Format, obj interface{}) error {
+	var (
+		out []byte
+		err error
+	)
+
+	switch f {
+	case JSON:
+		if out, err = json.MarshalIndent(obj, "", "  "); err != nil {
+			return err
+		}
+		out = append(out, '\n')
+	case YAML:
+		if out, err = yaml.Marshal(obj); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("format %q can't be used to print objects", f)
+	}
+
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,132 @@
+package printer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func testTable() Table {
+	t := Table{
+		Columns: []Column{
+			{Header: "Name"},
+			{Header: "Count"},
+			{Header: "Extra", Wide: true},
+		},
+	}
+	t.Append("foo", item{Name: "foo", Count: 1}, "foo", "1", "x")
+	t.Append("bar", item{Name: "bar", Count: 2}, "bar", "2", "y")
+	return t
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  Format
+		expectErr bool
+	}{
+		{name: "default", input: "", expected: Default},
+		{name: "json", input: "json", expected: JSON},
+		{name: "yaml", input: "yaml", expected: YAML},
+		{name: "wide", input: "wide", expected: Wide},
+		{name: "name", input: "name", expected: Name},
+		{name: "bogus", input: "xml", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseFormat(tt.input)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got format %q", f)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if f != tt.expected {
+				t.Errorf("expected %q, received %q", tt.expected, f)
+			}
+		})
+	}
+}
+
+func TestTablePrint(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		contains []string
+		excludes []string
+		expected string
+	}{
+		{
+			name:     "default",
+			format:   Default,
+			contains: []string{"Name", "Count", "foo", "bar"},
+			excludes: []string{"Extra", "x"},
+		},
+		{
+			name:     "wide",
+			format:   Wide,
+			contains: []string{"Name", "Count", "Extra", "foo", "x"},
+		},
+		{
+			name:     "name",
+			format:   Name,
+			expected: "foo\nbar\n",
+		},
+		{
+			name:     "json",
+			format:   JSON,
+			expected: "[\n  {\n    \"name\": \"foo\",\n    \"count\": 1\n  },\n  {\n    \"name\": \"bar\",\n    \"count\": 2\n  }\n]\n",
+		},
+		{
+			name:     "yaml",
+			format:   YAML,
+			expected: "- count: 1\n  name: foo\n- count: 2\n  name: bar\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.Buffer{}
+			if err := testTable().Print(&out, tt.format); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expected != "" && out.String() != tt.expected {
+				t.Errorf("\nexpected:\n%s\nsaw:\n%s", tt.expected, out.String())
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(out.String(), s) {
+					t.Errorf("expected output to contain %q:\n%s", s, out.String())
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(out.String(), s) {
+					t.Errorf("expected output not to contain %q:\n%s", s, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestEmptyTable(t *testing.T) {
+	out := bytes.Buffer{}
+	if err := (Table{}).Print(&out, JSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "[]\n" {
+		t.Errorf("expected empty list, saw %q", out.String())
+	}
+}
+
+func TestPrintObjectBadFormat(t *testing.T) {
+	if err := PrintObject(&bytes.Buffer{}, Wide, item{}); err == nil {
+		t.Errorf("expected error printing object as %q", Wide)
+	}
+}
@@ -0,0 +1,163 @@
+// Package pwpolicy checks API user passwords against a configurable
+// password policy.
+package pwpolicy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The character classes that a policy can require.
+const (
+	Lower  = "lower"
+	Upper  = "upper"
+	Digit  = "digit"
+	Symbol = "symbol"
+)
+
+// commonPasswords are rejected by every policy.
+var commonPasswords = []string{
+	"123456", "1234567", "12345678", "123456789", "1234567890",
+	"111111", "abc123", "admin", "changeme", "dragon", "iloveyou",
+	"letmein", "monkey", "passw0rd", "password", "password1",
+	"qwerty", "qwerty123", "secret", "sunshine", "trustno1", "welcome",
+}
+
+// Policy is a password policy. The field tags are the keys in the
+// epicctl config file.
+type Policy struct {
+	// MinLength is the minimum password length, in characters.
+	MinLength int `mapstructure:"min-length"`
+
+	// RequiredClasses are the character classes that a password must
+	// contain: lower, upper, digit and/or symbol.
+	RequiredClasses []string `mapstructure:"required-classes"`
+
+	// Denylist is a list of passwords that aren't allowed, in
+	// addition to a built-in list of common passwords. Matching is
+	// case-insensitive.
+	Denylist []string `mapstructure:"denylist"`
+
+	// DenylistFile is a file of denied passwords, one per line.
+	DenylistFile string `mapstructure:"denylist-file"`
+
+	// AllowUsername allows passwords that contain the username.
+	AllowUsername bool `mapstructure:"allow-username"`
+
+	// BcryptCost is the cost with which passwords are hashed.
+	BcryptCost int `mapstructure:"bcrypt-cost"`
+}
+
+// Default returns the policy that applies if the config file doesn't
+// have one.
+func Default() Policy {
+	return Policy{
+		MinLength:  6,
+		BcryptCost: bcrypt.DefaultCost,
+	}
+}
+
+// Validate checks that p makes sense.
+func (p *Policy) Validate() error {
+	if p.MinLength < 1 {
+		return fmt.Errorf("min-length must be at least 1")
+	}
+	for _, class := range p.RequiredClasses {
+		switch class {
+		case Lower, Upper, Digit, Symbol:
+		default:
+			return fmt.Errorf("unknown character class %q, must be one of %s, %s, %s or %s", class, Lower, Upper, Digit, Symbol)
+		}
+	}
+	if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt-cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return nil
+}
+
+// LoadDenylistFile adds the passwords in p's DenylistFile, if any, to
+// its Denylist. Blank lines and lines that start with "#" are
+// ignored.
+func (p *Policy) LoadDenylistFile() error {
+	if p.DenylistFile == "" {
+		return nil
+	}
+
+	f, err := os.Open(p.DenylistFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			p.Denylist = append(p.Denylist, line)
+		}
+	}
+	return scanner.Err()
+}
+
+// Check returns an error that explains each way in which password
+// violates p, or nil if it doesn't. username is the user whose
+// password it is.
+func (p *Policy) Check(password string, username string) error {
+	problems := []string{}
+
+	if length := len([]rune(password)); length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("it must be at least %d characters long", p.MinLength))
+	}
+
+	has := classes(password)
+	for _, class := range p.RequiredClasses {
+		if !has[class] {
+			problems = append(problems, fmt.Sprintf("it must contain a %s character", class))
+		}
+	}
+
+	lower := strings.ToLower(password)
+	for _, denied := range append(commonPasswords, p.Denylist...) {
+		if lower == strings.ToLower(denied) {
+			problems = append(problems, "it's too common")
+			break
+		}
+	}
+
+	if !p.AllowUsername && username != "" && strings.Contains(lower, strings.ToLower(username)) {
+		problems = append(problems, "it can't contain the username")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("password rejected: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Hash returns the bcrypt hash of password using p's cost.
+func (p *Policy) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+	return string(hash), err
+}
+
+// classes returns the character classes in s.
+func classes(s string) map[string]bool {
+	has := map[string]bool{}
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			has[Lower] = true
+		case unicode.IsUpper(r):
+			has[Upper] = true
+		case unicode.IsDigit(r):
+			has[Digit] = true
+		default:
+			has[Symbol] = true
+		}
+	}
+	return has
+}
@@ -0,0 +1,101 @@
+package pwpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCheck(t *testing.T) {
+	strict := Policy{
+		MinLength:       10,
+		RequiredClasses: []string{Lower, Upper, Digit, Symbol},
+		Denylist:        []string{"Acme-Corp-2022"},
+		BcryptCost:      bcrypt.MinCost,
+	}
+
+	tests := []struct {
+		name        string
+		policy      Policy
+		password    string
+		username    string
+		expectedErr string
+	}{
+		{name: "default ok", policy: Default(), password: "hunter22", username: "alice"},
+		{name: "default too short", policy: Default(), password: "abc", username: "alice", expectedErr: "at least 6 characters"},
+		{name: "common", policy: Default(), password: "PassWord", username: "alice", expectedErr: "too common"},
+		{name: "contains username", policy: Default(), password: "xxALICExx", username: "alice", expectedErr: "can't contain the username"},
+		{name: "username allowed", policy: Policy{MinLength: 6, AllowUsername: true}, password: "xxALICExx", username: "alice"},
+		{name: "strict ok", policy: strict, password: "Tr0ub4dor&3x", username: "alice"},
+		{name: "missing classes", policy: strict, password: "troubadorxx", username: "alice", expectedErr: "upper character; it must contain a digit character; it must contain a symbol"},
+		{name: "custom denylist", policy: strict, password: "acme-corp-2022", username: "alice", expectedErr: "too common"},
+		{name: "multibyte length", policy: Policy{MinLength: 4}, password: "ééé", expectedErr: "at least 4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.password, tt.username)
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("err %v doesn't match expected %q", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    Policy
+		expectErr bool
+	}{
+		{name: "default", policy: Default()},
+		{name: "zero length", policy: Policy{BcryptCost: 10}, expectErr: true},
+		{name: "bad class", policy: Policy{MinLength: 1, BcryptCost: 10, RequiredClasses: []string{"emoji"}}, expectErr: true},
+		{name: "cheap cost", policy: Policy{MinLength: 1, BcryptCost: 1}, expectErr: true},
+		{name: "expensive cost", policy: Policy{MinLength: 1, BcryptCost: 32}, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.expectErr {
+				t.Errorf("expected error %v, saw %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadDenylistFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist")
+	if err := os.WriteFile(path, []byte("# team names\nrockets\n\n  jets  \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := Default()
+	p.DenylistFile = path
+	if err := p.LoadDenylistFile(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.Check("JETS", ""); err == nil {
+		t.Error("expected a denylisted password to be rejected")
+	}
+}
+
+func TestHash(t *testing.T) {
+	p := Default()
+	p.BcryptCost = bcrypt.MinCost + 1
+
+	hash, err := p.Hash("hunter22")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cost, _ := bcrypt.Cost([]byte(hash)); cost != p.BcryptCost {
+		t.Errorf("expected cost %d, saw %d", p.BcryptCost, cost)
+	}
+}
@@ -0,0 +1,260 @@
+// Package routerule parses the compact routing rules that epicctl
+// accepts on the command line into Gateway API HTTPRoute rules.
+//
+// A rule is a comma-separated list of key=value terms:
+//
+//	prefix=/api          match paths that start with /api
+//	path=/login          match the path /login exactly
+//	header=Name:value    match requests whose Name header is value
+//	backend=cluster[:N]  send matching requests to an endpoint cluster
+//
+// A rule has at most one prefix or path, any number of headers, and
+// at least one backend. If a rule has more than one backend then the
+// weights (N, default 1) split the traffic between them, e.g., for
+// canary releases:
+//
+//	prefix=/,backend=stable:90,backend=canary:10
+//
+// A backslash makes the next character literal, so paths and header
+// values can contain commas, e.g., header=Accept:a\,b matches the
+// Accept header "a,b".
+package routerule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// maxWeight is the largest backend weight that the Gateway API
+// allows.
+const maxWeight = 1000000
+
+// Parse parses rule into an HTTPRouteRule. The rule's backends
+// receive traffic on port.
+func Parse(rule string, port int32) (v1alpha2.HTTPRouteRule, error) {
+	var (
+		path     *v1alpha2.HTTPPathMatch
+		headers  []v1alpha2.HTTPHeaderMatch
+		backends []v1alpha2.HTTPBackendRef
+	)
+
+	terms, err := splitTerms(rule)
+	if err != nil {
+		return v1alpha2.HTTPRouteRule{}, err
+	}
+
+	for _, term := range terms {
+		key, value, found := strings.Cut(term, "=")
+		if !found {
+			return v1alpha2.HTTPRouteRule{}, fmt.Errorf("%q is not key=value, commas in values must be escaped with \\", term)
+		}
+
+		switch key {
+		case "prefix", "path":
+			if path != nil {
+				return v1alpha2.HTTPRouteRule{}, fmt.Errorf("only one prefix or path is allowed")
+			}
+			match, err := parsePath(key, value)
+			if err != nil {
+				return v1alpha2.HTTPRouteRule{}, err
+			}
+			path = match
+		case "header":
+			match, err := parseHeader(value)
+			if err != nil {
+				return v1alpha2.HTTPRouteRule{}, err
+			}
+			headers = append(headers, match)
+		case "backend":
+			backend, err := ParseBackend(value, port)
+			if err != nil {
+				return v1alpha2.HTTPRouteRule{}, err
+			}
+			backends = append(backends, backend)
+		default:
+			return v1alpha2.HTTPRouteRule{}, fmt.Errorf("unknown key %q, expected prefix, path, header or backend", key)
+		}
+	}
+
+	if len(backends) == 0 {
+		return v1alpha2.HTTPRouteRule{}, fmt.Errorf("at least one backend is required")
+	}
+
+	parsed := v1alpha2.HTTPRouteRule{BackendRefs: backends}
+	if path != nil || len(headers) > 0 {
+		parsed.Matches = []v1alpha2.HTTPRouteMatch{{
+			Path:    path,
+			Headers: headers,
+		}}
+	}
+
+	return parsed, nil
+}
+
+// splitTerms splits rule at its commas, except for the ones that are
+// escaped with a backslash. Escaped characters are unescaped.
+func splitTerms(rule string) ([]string, error) {
+	terms := []string{}
+	term := strings.Builder{}
+	escaped := false
+	for _, c := range rule {
+		switch {
+		case escaped:
+			term.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == ',':
+			terms = append(terms, term.String())
+			term.Reset()
+		default:
+			term.WriteRune(c)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("rule %q ends with an unfinished \\ escape", rule)
+	}
+
+	return append(terms, term.String()), nil
+}
+
+// escape escapes the characters in value that Parse and Format treat
+// as separators.
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`).Replace(value)
+}
+
+// Backend returns a rule that sends all traffic to the endpoint
+// cluster clusterName on port.
+func Backend(clusterName string, port int32) v1alpha2.HTTPRouteRule {
+	portNum := v1alpha2.PortNumber(port)
+	return v1alpha2.HTTPRouteRule{
+		BackendRefs: []v1alpha2.HTTPBackendRef{{
+			BackendRef: v1alpha2.BackendRef{
+				BackendObjectReference: v1alpha2.BackendObjectReference{
+					Name: v1alpha2.ObjectName(clusterName),
+					Port: &portNum,
+				},
+			},
+		}},
+	}
+}
+
+// ValidateHostname checks that hostname can be used as an HTTPRoute
+// hostname, i.e., it's a DNS name that can start with "*.".
+func ValidateHostname(hostname string) error {
+	name := strings.TrimPrefix(hostname, "*.")
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid hostname %q: %s", hostname, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// parsePath parses the value of a prefix or path term.
+func parsePath(key string, value string) (*v1alpha2.HTTPPathMatch, error) {
+	if !strings.HasPrefix(value, "/") {
+		return nil, fmt.Errorf("%s %q must start with /", key, value)
+	}
+
+	matchType := v1alpha2.PathMatchPathPrefix
+	if key == "path" {
+		matchType = v1alpha2.PathMatchExact
+	}
+
+	return &v1alpha2.HTTPPathMatch{
+		Type:  &matchType,
+		Value: &value,
+	}, nil
+}
+
+// parseHeader parses the value of a header term, Name:value.
+func parseHeader(value string) (v1alpha2.HTTPHeaderMatch, error) {
+	name, headerValue, found := strings.Cut(value, ":")
+	if !found || name == "" {
+		return v1alpha2.HTTPHeaderMatch{}, fmt.Errorf("header %q is not Name:value", value)
+	}
+	if errs := validation.IsHTTPHeaderName(name); len(errs) > 0 {
+		return v1alpha2.HTTPHeaderMatch{}, fmt.Errorf("invalid header name %q: %s", name, strings.Join(errs, ", "))
+	}
+
+	matchType := v1alpha2.HeaderMatchExact
+	return v1alpha2.HTTPHeaderMatch{
+		Type:  &matchType,
+		Name:  v1alpha2.HTTPHeaderName(name),
+		Value: headerValue,
+	}, nil
+}
+
+// ParseBackend parses the value of a backend term, cluster[:weight],
+// into a reference to the endpoint cluster on port.
+func ParseBackend(value string, port int32) (v1alpha2.HTTPBackendRef, error) {
+	clusterName, weightValue, hasWeight := strings.Cut(value, ":")
+	if errs := validation.IsDNS1123Subdomain(clusterName); len(errs) > 0 {
+		return v1alpha2.HTTPBackendRef{}, fmt.Errorf("invalid backend cluster name %q: %s", clusterName, strings.Join(errs, ", "))
+	}
+
+	backend := Backend(clusterName, port).BackendRefs[0]
+
+	if hasWeight {
+		weight, err := strconv.ParseInt(weightValue, 10, 32)
+		if err != nil || weight < 0 || weight > maxWeight {
+			return v1alpha2.HTTPBackendRef{}, fmt.Errorf("backend %s: weight must be between 0 and %d", value, maxWeight)
+		}
+		w := int32(weight)
+		backend.Weight = &w
+	}
+
+	return backend, nil
+}
+
+// Format is the inverse of Parse: it renders rule in the rule syntax,
+// leaving out the backend ports. Rules can have more than one match,
+// which Parse can't express, so each match's terms are separated from
+// the next's by ";". Commas, semicolons and backslashes in values are
+// escaped with a backslash. Match types that Parse doesn't support are
+// rendered as their lower-case type names, e.g.,
+// "regularexpression=^/v[0-9]+/".
+func Format(rule v1alpha2.HTTPRouteRule) string {
+	matches := []string{}
+	for _, match := range rule.Matches {
+		terms := []string{}
+		if match.Path != nil && match.Path.Value != nil {
+			key := "prefix"
+			if match.Path.Type != nil {
+				switch *match.Path.Type {
+				case v1alpha2.PathMatchPathPrefix:
+					// The Gateway API's default, and ours.
+				case v1alpha2.PathMatchExact:
+					key = "path"
+				default:
+					key = strings.ToLower(string(*match.Path.Type))
+				}
+			}
+			terms = append(terms, key+"="+escape(*match.Path.Value))
+		}
+		for _, header := range match.Headers {
+			terms = append(terms, fmt.Sprintf("header=%s:%s", header.Name, escape(header.Value)))
+		}
+		if len(terms) > 0 {
+			matches = append(matches, strings.Join(terms, ","))
+		}
+	}
+
+	backends := []string{}
+	for _, backend := range rule.BackendRefs {
+		term := "backend=" + string(backend.Name)
+		if backend.Weight != nil {
+			term += fmt.Sprintf(":%d", *backend.Weight)
+		}
+		backends = append(backends, term)
+	}
+
+	if len(matches) == 0 {
+		return strings.Join(backends, ",")
+	}
+	return strings.Join(matches, ";") + "," + strings.Join(backends, ",")
+}
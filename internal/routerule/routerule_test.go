@@ -0,0 +1,194 @@
+package routerule
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestParse(t *testing.T) {
+	port := v1alpha2.PortNumber(8080)
+	prefix := v1alpha2.PathMatchPathPrefix
+	exact := v1alpha2.PathMatchExact
+	headerExact := v1alpha2.HeaderMatchExact
+	api := "/api"
+	login := "/login"
+	commaPath := "/a,b"
+	ninety := int32(90)
+	ten := int32(10)
+
+	backend := func(name string, weight *int32) v1alpha2.HTTPBackendRef {
+		return v1alpha2.HTTPBackendRef{
+			BackendRef: v1alpha2.BackendRef{
+				BackendObjectReference: v1alpha2.BackendObjectReference{
+					Name: v1alpha2.ObjectName(name),
+					Port: &port,
+				},
+				Weight: weight,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		expected v1alpha2.HTTPRouteRule
+		wantErr  bool
+	}{
+		{
+			name:     "backend only",
+			rule:     "backend=linux-nodes",
+			expected: v1alpha2.HTTPRouteRule{BackendRefs: []v1alpha2.HTTPBackendRef{backend("linux-nodes", nil)}},
+		},
+		{
+			name: "path prefix",
+			rule: "prefix=/api,backend=api-nodes",
+			expected: v1alpha2.HTTPRouteRule{
+				Matches:     []v1alpha2.HTTPRouteMatch{{Path: &v1alpha2.HTTPPathMatch{Type: &prefix, Value: &api}}},
+				BackendRefs: []v1alpha2.HTTPBackendRef{backend("api-nodes", nil)},
+			},
+		},
+		{
+			name: "exact path and headers",
+			rule: "path=/login,header=X-Env:canary,header=X-Beta:,backend=login-nodes",
+			expected: v1alpha2.HTTPRouteRule{
+				Matches: []v1alpha2.HTTPRouteMatch{{
+					Path: &v1alpha2.HTTPPathMatch{Type: &exact, Value: &login},
+					Headers: []v1alpha2.HTTPHeaderMatch{
+						{Type: &headerExact, Name: "X-Env", Value: "canary"},
+						{Type: &headerExact, Name: "X-Beta", Value: ""},
+					},
+				}},
+				BackendRefs: []v1alpha2.HTTPBackendRef{backend("login-nodes", nil)},
+			},
+		},
+		{
+			name: "weighted backends",
+			rule: "backend=stable:90,backend=canary:10",
+			expected: v1alpha2.HTTPRouteRule{
+				BackendRefs: []v1alpha2.HTTPBackendRef{backend("stable", &ninety), backend("canary", &ten)},
+			},
+		},
+		{
+			name: "escaped comma",
+			rule: `prefix=/a\,b,header=Accept:text/html\,application/json,backend=a`,
+			expected: v1alpha2.HTTPRouteRule{
+				Matches: []v1alpha2.HTTPRouteMatch{{
+					Path:    &v1alpha2.HTTPPathMatch{Type: &prefix, Value: &commaPath},
+					Headers: []v1alpha2.HTTPHeaderMatch{{Type: &headerExact, Name: "Accept", Value: "text/html,application/json"}},
+				}},
+				BackendRefs: []v1alpha2.HTTPBackendRef{backend("a", nil)},
+			},
+		},
+		{name: "no backend", rule: "prefix=/api", wantErr: true},
+		{name: "unescaped comma", rule: "header=Accept:text/html,application/json,backend=a", wantErr: true},
+		{name: "unfinished escape", rule: `backend=a\`, wantErr: true},
+		{name: "two paths", rule: "prefix=/api,path=/login,backend=a", wantErr: true},
+		{name: "relative path", rule: "prefix=api,backend=a", wantErr: true},
+		{name: "unknown key", rule: "method=GET,backend=a", wantErr: true},
+		{name: "not key=value", rule: "backend", wantErr: true},
+		{name: "bad header", rule: "header=X-Env,backend=a", wantErr: true},
+		{name: "bad header name", rule: "header=X Env:a,backend=a", wantErr: true},
+		{name: "bad cluster name", rule: "backend=Linux_Nodes", wantErr: true},
+		{name: "bad weight", rule: "backend=a:heavy", wantErr: true},
+		{name: "negative weight", rule: "backend=a:-1", wantErr: true},
+		{name: "weight too big", rule: "backend=a:1000001", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule, 8080)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, saw %#v", rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rule, tt.expected) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, rule)
+			}
+		})
+	}
+}
+
+func TestValidateHostname(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostname string
+		wantErr  bool
+	}{
+		{name: "plain", hostname: "www.example.org"},
+		{name: "wildcard", hostname: "*.example.org"},
+		{name: "upper case", hostname: "WWW.example.org", wantErr: true},
+		{name: "embedded wildcard", hostname: "www.*.example.org", wantErr: true},
+		{name: "empty", hostname: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateHostname(tt.hostname); (err != nil) != tt.wantErr {
+				t.Errorf("\nexpected error: %v\nsaw: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	regex := v1alpha2.PathMatchRegularExpression
+	version := "^/v[0-9]+/"
+	login := "/login"
+	exact := v1alpha2.PathMatchExact
+
+	tests := []struct {
+		name     string
+		rule     v1alpha2.HTTPRouteRule
+		expected string
+	}{
+		{
+			name:     "backend only",
+			rule:     Backend("linux-nodes", 80),
+			expected: "backend=linux-nodes",
+		},
+		{
+			name: "regular expression and two matches",
+			rule: v1alpha2.HTTPRouteRule{
+				Matches: []v1alpha2.HTTPRouteMatch{
+					{Path: &v1alpha2.HTTPPathMatch{Type: &regex, Value: &version}},
+					{Path: &v1alpha2.HTTPPathMatch{Type: &exact, Value: &login}},
+				},
+				BackendRefs: Backend("a", 80).BackendRefs,
+			},
+			expected: "regularexpression=^/v[0-9]+/;path=/login,backend=a",
+		},
+	}
+
+	// Everything that Parse accepts should survive a round trip.
+	for _, rule := range []string{
+		"prefix=/api,backend=api-nodes",
+		"path=/login,header=X-Env:canary,header=X-Beta:,backend=login-nodes",
+		"backend=stable:90,backend=canary:10",
+		`prefix=/a\,b,header=Accept:text/html\,application/json,backend=a`,
+	} {
+		parsed, err := Parse(rule, 80)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tests = append(tests, struct {
+			name     string
+			rule     v1alpha2.HTTPRouteRule
+			expected string
+		}{name: "round trip " + rule, rule: parsed, expected: rule})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if saw := Format(tt.rule); saw != tt.expected {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, saw)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Package tenant defines the declarative specification of an EPIC
+// tenant, i.e., a user namespace and everything in it.
+package tenant
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	"epic-gateway.org/epicctl/internal/htpasswd"
+)
+
+const (
+	// DefaultServiceGroup is the service group to which Gateways
+	// belong if their spec doesn't say otherwise.
+	DefaultServiceGroup = "gatewayhttps"
+
+	// DefaultClusterName is the endpoint cluster to which Endpoints
+	// belong, and Gateways route, if their spec doesn't say otherwise.
+	DefaultClusterName = "linux-nodes"
+)
+
+// Spec describes a tenant.
+type Spec struct {
+	// Name is the name of the user namespace (without the "epic-"
+	// prefix).
+	Name string `json:"name"`
+
+	// Registry holds the credentials for the private Docker
+	// registry. If it's nil then the registry secret isn't managed.
+	Registry *Registry `json:"registry,omitempty"`
+
+	APIUsers  []APIUser  `json:"apiUsers,omitempty"`
+	Gateways  []Gateway  `json:"gateways,omitempty"`
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+}
+
+// Registry holds Docker registry credentials.
+type Registry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// APIUser is a web service user. Passwords are never stored in the
+// spec, only their hashes.
+type APIUser struct {
+	Name         string `json:"name"`
+	PasswordHash string `json:"passwordHash"`
+}
+
+// Gateway is an ad-hoc Gateway.
+type Gateway struct {
+	Name         string `json:"name"`
+	Port         int32  `json:"port"`
+	ServiceGroup string `json:"serviceGroup,omitempty"`
+
+	// ClusterName is the endpoint cluster to which the Gateway routes
+	// its traffic.
+	ClusterName string `json:"clusterName,omitempty"`
+}
+
+// Endpoint is an ad-hoc endpoint, i.e., a Linux host that receives
+// traffic from EPIC.
+type Endpoint struct {
+	// Name is the endpoint's hostname.
+	Name        string `json:"name"`
+	ClusterName string `json:"clusterName,omitempty"`
+	Address     string `json:"address"`
+	Port        int32  `json:"port"`
+}
+
+// Load reads, defaults and validates the spec in the file at path.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse parses, defaults and validates a YAML (or JSON) spec.
+// Unknown fields are errors so typos don't go unnoticed.
+func Parse(data []byte) (*Spec, error) {
+	spec := Spec{}
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, err
+	}
+
+	spec.Default()
+
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
+
+// Default fills in the optional fields that aren't set.
+func (s *Spec) Default() {
+	for i := range s.Gateways {
+		if s.Gateways[i].ServiceGroup == "" {
+			s.Gateways[i].ServiceGroup = DefaultServiceGroup
+		}
+		if s.Gateways[i].ClusterName == "" {
+			s.Gateways[i].ClusterName = DefaultClusterName
+		}
+	}
+	for i := range s.Endpoints {
+		if s.Endpoints[i].ClusterName == "" {
+			s.Endpoints[i].ClusterName = DefaultClusterName
+		}
+	}
+}
+
+// Validate checks that s is complete and consistent.
+func (s *Spec) Validate() error {
+	if err := validName("name", s.Name); err != nil {
+		return err
+	}
+
+	if s.Registry != nil && (s.Registry.Username == "" || s.Registry.Password == "") {
+		return fmt.Errorf("registry: username and password are required")
+	}
+
+	users := map[string]bool{}
+	for i, user := range s.APIUsers {
+		field := fmt.Sprintf("apiUsers[%d]", i)
+		if err := htpasswd.ValidateUser(user.Name); err != nil {
+			return fmt.Errorf("%s.name: %w", field, err)
+		}
+		if users[user.Name] {
+			return fmt.Errorf("%s.name: duplicate user %q", field, user.Name)
+		}
+		users[user.Name] = true
+		if htpasswd.DetectAlgorithm(user.PasswordHash) != htpasswd.Bcrypt {
+			return fmt.Errorf("%s.passwordHash: must be a bcrypt hash", field)
+		}
+	}
+
+	gateways := map[string]bool{}
+	for i, gw := range s.Gateways {
+		field := fmt.Sprintf("gateways[%d]", i)
+		if err := validName(field+".name", gw.Name); err != nil {
+			return err
+		}
+		if gateways[gw.Name] {
+			return fmt.Errorf("%s.name: duplicate gateway %q", field, gw.Name)
+		}
+		gateways[gw.Name] = true
+		if err := validPort(field+".port", gw.Port); err != nil {
+			return err
+		}
+	}
+
+	endpoints := map[string]bool{}
+	for i, ep := range s.Endpoints {
+		field := fmt.Sprintf("endpoints[%d]", i)
+		if err := validName(field+".name", ep.Name); err != nil {
+			return err
+		}
+		if endpoints[ep.Name] {
+			return fmt.Errorf("%s.name: duplicate endpoint %q", field, ep.Name)
+		}
+		endpoints[ep.Name] = true
+		if net.ParseIP(ep.Address) == nil {
+			return fmt.Errorf("%s.address: can't parse %q as an IP address", field, ep.Address)
+		}
+		if err := validPort(field+".port", ep.Port); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validName checks that name can be used as a Kubernetes object name.
+func validName(field string, name string) error {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("%s: %q is not a valid name: %s", field, name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// validPort checks that port is a valid TCP/UDP port number.
+func validPort(field string, port int32) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%s: %d is not a valid port", field, port)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package tenant
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const hash = "$2y$10$ZGGm5kBY5tg7BU1BNkXlDuZ7YBjXsfYd1jDNzLd0k0uJ7JXk1K4lW"
+
+func TestParse(t *testing.T) {
+	spec, err := Parse([]byte(`
+name: acme
+registry:
+  username: reg-user
+  password: reg-password
+apiUsers:
+- name: alice
+  passwordHash: "` + hash + `"
+gateways:
+- name: web
+  port: 80
+endpoints:
+- name: node1
+  address: 192.168.1.10
+  port: 8080
+  clusterName: web-nodes
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &Spec{
+		Name:      "acme",
+		Registry:  &Registry{Username: "reg-user", Password: "reg-password"},
+		APIUsers:  []APIUser{{Name: "alice", PasswordHash: hash}},
+		Gateways:  []Gateway{{Name: "web", Port: 80, ServiceGroup: DefaultServiceGroup, ClusterName: DefaultClusterName}},
+		Endpoints: []Endpoint{{Name: "node1", ClusterName: "web-nodes", Address: "192.168.1.10", Port: 8080}},
+	}
+	if !reflect.DeepEqual(spec, expected) {
+		t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", expected, spec)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		expectedErr string
+	}{
+		{name: "unknown field", spec: "name: acme\ngatways: []", expectedErr: "unknown field"},
+		{name: "bad name", spec: "name: Acme_Corp", expectedErr: "name:"},
+		{name: "half registry", spec: "name: acme\nregistry: {username: x}", expectedErr: "registry"},
+		{name: "plaintext password", spec: "name: acme\napiUsers: [{name: alice, passwordHash: secret}]", expectedErr: "apiUsers[0].passwordHash"},
+		{name: "bad user", spec: "name: acme\napiUsers: [{name: 'a:b', passwordHash: '" + hash + "'}]", expectedErr: "apiUsers[0].name"},
+		{name: "duplicate user", spec: "name: acme\napiUsers: [{name: a, passwordHash: '" + hash + "'}, {name: a, passwordHash: '" + hash + "'}]", expectedErr: "apiUsers[1].name"},
+		{name: "bad port", spec: "name: acme\ngateways: [{name: web, port: 0}]", expectedErr: "gateways[0].port"},
+		{name: "duplicate gateway", spec: "name: acme\ngateways: [{name: web, port: 80}, {name: web, port: 81}]", expectedErr: "gateways[1].name"},
+		{name: "bad address", spec: "name: acme\nendpoints: [{name: n, address: nowhere, port: 80}]", expectedErr: "endpoints[0].address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.spec))
+			if err == nil {
+				t.Fatalf("expected error containing %q", tt.expectedErr)
+			}
+			if !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("err %v doesn't match expected %q", err, tt.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Package textdiff produces line-based unified diffs.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// op is the kind of an edit.
+type op byte
+
+const (
+	opKeep   op = ' '
+	opDelete op = '-'
+	opInsert op = '+'
+)
+
+// edit is one line of an edit script.
+type edit struct {
+	op   op
+	text string
+
+	// aLine and bLine are the 0-based line numbers in a and b that
+	// follow this edit.
+	aLine, bLine int
+}
+
+// Unified returns the unified diff, with context lines of context,
+// that turns a into b. The names label the two sides in the header.
+// If a and b are the same then it returns "".
+func Unified(aName string, bName string, a string, b string, context int) string {
+	if a == b {
+		return ""
+	}
+
+	edits := script(splitLines(a), splitLines(b))
+
+	out := strings.Builder{}
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for _, h := range hunks(edits, context) {
+		writeHunk(&out, edits[h[0]:h[1]])
+	}
+
+	return out.String()
+}
+
+// splitLines splits s into lines, dropping the final newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// script returns the shortest edit script that turns a into b, using
+// the longest common subsequence of their lines. Our inputs are
+// small so the quadratic table is fine.
+func script(a []string, b []string) []edit {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	edits := []edit{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{op: opKeep, text: a[i], aLine: i, bLine: j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{op: opDelete, text: a[i], aLine: i, bLine: j})
+			i++
+		default:
+			edits = append(edits, edit{op: opInsert, text: b[j], aLine: i, bLine: j})
+			j++
+		}
+	}
+
+	return edits
+}
+
+// hunks returns the [start, end) ranges of edits that make up each
+// hunk: the changes plus up to context unchanged lines on either
+// side. Hunks whose context would overlap are merged.
+func hunks(edits []edit, context int) [][2]int {
+	ranges := [][2]int{}
+	for i, e := range edits {
+		if e.op == opKeep {
+			continue
+		}
+		start, end := i-context, i+context+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(edits) {
+			end = len(edits)
+		}
+		if n := len(ranges); n > 0 && start <= ranges[n-1][1] {
+			ranges[n-1][1] = end
+		} else {
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+	return ranges
+}
+
+// writeHunk writes the header and lines of one hunk to out.
+func writeHunk(out *strings.Builder, edits []edit) {
+	aCount, bCount := 0, 0
+	for _, e := range edits {
+		if e.op != opInsert {
+			aCount++
+		}
+		if e.op != opDelete {
+			bCount++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(edits[0].aLine, aCount), hunkRange(edits[0].bLine, bCount))
+	for _, e := range edits {
+		fmt.Fprintf(out, "%c%s\n", e.op, e.text)
+	}
+}
+
+// hunkRange formats the line range of one side of a hunk. By
+// convention an empty range is numbered from the line before it.
+func hunkRange(line int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line+1)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
@@ -0,0 +1,59 @@
+package textdiff
+
+import (
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected string
+	}{
+		{
+			name:     "same",
+			a:        "a\nb\n",
+			b:        "a\nb\n",
+			expected: "",
+		},
+		{
+			name:     "created",
+			a:        "",
+			b:        "a\nb\n",
+			expected: "--- live\n+++ spec\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "deleted",
+			a:        "a\n",
+			b:        "",
+			expected: "--- live\n+++ spec\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name:     "changed with context",
+			a:        "1\n2\n3\n4\n5\n6\n7\n",
+			b:        "1\n2\n3\nfour\n5\n6\n7\n",
+			expected: "--- live\n+++ spec\n@@ -3,3 +3,3 @@\n 3\n-4\n+four\n 5\n",
+		},
+		{
+			name:     "separate hunks",
+			a:        "1\n2\n3\n4\n5\n6\n7\n",
+			b:        "one\n2\n3\n4\n5\n6\nseven\n",
+			expected: "--- live\n+++ spec\n@@ -1,2 +1,2 @@\n-1\n+one\n 2\n@@ -6,2 +6,2 @@\n 6\n-7\n+seven\n",
+		},
+		{
+			name:     "merged hunks",
+			a:        "1\n2\n3\n4\n",
+			b:        "one\n2\n3\nfour\n",
+			expected: "--- live\n+++ spec\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n-4\n+four\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saw := Unified("live", "spec", tt.a, tt.b, 1)
+			if saw != tt.expected {
+				t.Errorf("\nexpected:\n%s\nsaw:\n%s", tt.expected, saw)
+			}
+		})
+	}
+}
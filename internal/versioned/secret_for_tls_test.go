@@ -0,0 +1,148 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioned
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// writeKeyPair writes a self-signed certificate and its private key
+// to dir, and returns their contents.
+func writeKeyPair(t *testing.T, dir string) (cert []byte, key []byte) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.org"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	key = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := ioutil.WriteFile(filepath.Join(dir, "tls.crt"), cert, 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "tls.key"), key, 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return cert, key
+}
+
+func TestSecretForTLSGenerate(t *testing.T) {
+	dir := t.TempDir()
+	cert, key := writeKeyPair(t, dir)
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+
+	tests := []struct {
+		name      string
+		params    map[string]interface{}
+		expected  *v1.Secret
+		expectErr bool
+	}{
+		{
+			name: "test-valid-tls-secret",
+			params: map[string]interface{}{
+				"name": "foo",
+				"key":  keyPath,
+				"cert": certPath,
+			},
+			expected: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+				Data: map[string][]byte{
+					v1.TLSCertKey:       cert,
+					v1.TLSPrivateKeyKey: key,
+				},
+				Type: v1.SecretTypeTLS,
+			},
+			expectErr: false,
+		},
+		{
+			name: "test-swapped-cert-and-key",
+			params: map[string]interface{}{
+				"name": "foo",
+				"key":  certPath,
+				"cert": keyPath,
+			},
+			expectErr: true,
+		},
+		{
+			name: "test-missing-file",
+			params: map[string]interface{}{
+				"name": "foo",
+				"key":  filepath.Join(dir, "missing.key"),
+				"cert": certPath,
+			},
+			expectErr: true,
+		},
+		{
+			name: "test-missing-required-param",
+			params: map[string]interface{}{
+				"name": "foo",
+				"cert": certPath,
+			},
+			expectErr: true,
+		},
+	}
+
+	generator := SecretForTLSGeneratorV1{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := generator.Generate(tt.params)
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, saw none")
+				}
+				return
+			}
+			if !reflect.DeepEqual(obj.(*v1.Secret), tt.expected) {
+				t.Errorf("\nexpected:\n%#v\nsaw:\n%#v", tt.expected, obj.(*v1.Secret))
+			}
+		})
+	}
+}